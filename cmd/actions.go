@@ -0,0 +1,302 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lunarway/shuttle/pkg/config"
+	"github.com/lunarway/shuttle/pkg/executors/golang/cache"
+	"github.com/lunarway/shuttle/pkg/executors/golang/compile"
+	"github.com/lunarway/shuttle/pkg/executors/golang/executer"
+	"github.com/lunarway/shuttle/pkg/ui"
+)
+
+// newActions manages golang actions. contextProvider must not compile golang actions, so the commands work even if
+// they fail to compile
+func newActions(uii *ui.UI, contextProvider contextProvider) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "actions",
+		Short: "Manage golang actions",
+	}
+
+	cmd.AddCommand(
+		newActionsBuild(uii, contextProvider),
+		newActionsLs(uii, contextProvider),
+		newActionsClean(uii, contextProvider),
+		newActionsDoctor(uii, contextProvider),
+		newActionsInit(uii, contextProvider),
+		newActionsTest(uii, contextProvider),
+		newActionsExport(uii, contextProvider),
+		newActionsCache(uii),
+	)
+
+	return cmd
+}
+
+func shuttleFilePath(projectContext config.ShuttleProjectContext) string {
+	return fmt.Sprintf("%s/shuttle.yaml", projectContext.ProjectPath)
+}
+
+func newActionsBuild(uii *ui.UI, contextProvider contextProvider) *cobra.Command {
+	return &cobra.Command{
+		Use:          "build",
+		Short:        "Compile the golang actions of the project and its plan, ignoring cached binaries",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// show the output of the go tool
+			uii.SetContext(ui.LevelVerbose)
+
+			projectContext, err := contextProvider()
+			if err != nil {
+				return err
+			}
+
+			binaries, err := executer.Build(cmd.Context(), uii, shuttleFilePath(projectContext), &projectContext)
+			if err != nil {
+				return err
+			}
+
+			if binaries.Local.Path == "" && binaries.Plan.Path == "" {
+				uii.Infoln("No golang actions found")
+				return nil
+			}
+			if binaries.Local.Path != "" {
+				uii.Infoln("Built local actions: %s", binaries.Local.Path)
+			}
+			if binaries.Plan.Path != "" {
+				uii.Infoln("Built plan actions: %s", binaries.Plan.Path)
+			}
+
+			return nil
+		},
+	}
+}
+
+func newActionsLs(uii *ui.UI, contextProvider contextProvider) *cobra.Command {
+	return &cobra.Command{
+		Use:          "ls",
+		Short:        "List the golang actions of the project and its plan, and which one is used",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			projectContext, err := contextProvider()
+			if err != nil {
+				return err
+			}
+
+			local, plan, err := executer.ListBySource(cmd.Context(), uii, shuttleFilePath(projectContext), &projectContext)
+			if err != nil {
+				return err
+			}
+
+			names := make([]string, 0, len(local.Actions)+len(plan.Actions))
+			for name := range local.Actions {
+				names = append(names, name)
+			}
+			for name := range plan.Actions {
+				if _, ok := local.Actions[name]; !ok {
+					names = append(names, name)
+				}
+			}
+			sort.Strings(names)
+
+			yesNo := func(ok bool) string {
+				if ok {
+					return "yes"
+				}
+				return "-"
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
+			fmt.Fprintln(w, "ACTION\tLOCAL\tPLAN\tUSED")
+			for _, name := range names {
+				_, inLocal := local.Actions[name]
+				_, inPlan := plan.Actions[name]
+
+				// executer runs the local action if both declare it, and golang actions replace scripts of the same name
+				used := "plan"
+				if inLocal {
+					used = "local"
+				}
+
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", name, yesNo(inLocal), yesNo(inPlan), used)
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+
+			if !executer.IsEnabled() {
+				uii.Infoln("\nGolang actions are disabled, set SHUTTLE_GOLANG_ACTIONS=true to make them available in shuttle run")
+			}
+
+			return nil
+		},
+	}
+}
+
+func newActionsClean(uii *ui.UI, contextProvider contextProvider) *cobra.Command {
+	var cleanCache bool
+
+	cmd := &cobra.Command{
+		Use:          "clean",
+		Short:        "Remove the compiled golang actions binaries of the project and its plan",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			projectContext, err := contextProvider()
+			if err != nil {
+				return err
+			}
+
+			removed, err := executer.Clean(cmd.Context(), shuttleFilePath(projectContext), &projectContext)
+			for _, dir := range removed {
+				uii.Infoln("Removed %s", dir)
+			}
+			if err != nil {
+				return err
+			}
+
+			if cleanCache {
+				actionsCache, err := cache.Default()
+				if err != nil {
+					return err
+				}
+				entries, err := actionsCache.Prune(0)
+				if err != nil {
+					return err
+				}
+				uii.Infoln("Removed %d binaries from %s", len(entries), actionsCache.Dir())
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&cleanCache, "cache", false, "also empty the user level cache of binaries shared by all projects")
+
+	return cmd
+}
+
+func newActionsDoctor(uii *ui.UI, contextProvider contextProvider) *cobra.Command {
+	return &cobra.Command{
+		Use:          "doctor",
+		Short:        "Explain why golang actions are disabled or failing",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			projectContext, err := contextProvider()
+			if err != nil {
+				return err
+			}
+
+			diagnostics := executer.Diagnose(cmd.Context(), uii, shuttleFilePath(projectContext), &projectContext)
+
+			failed := false
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
+			for _, diagnostic := range diagnostics {
+				if diagnostic.Status == executer.DiagnosticError {
+					failed = true
+				}
+				fmt.Fprintf(w, "[%s]\t%s\t%s\n", diagnostic.Status, diagnostic.Check, diagnostic.Message)
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+
+			if failed {
+				return fmt.Errorf("golang actions are not working")
+			}
+
+			return nil
+		},
+	}
+}
+
+func newActionsInit(uii *ui.UI, contextProvider contextProvider) *cobra.Command {
+	return &cobra.Command{
+		Use:          "init",
+		Short:        "Create an actions module with a sample golang action",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			projectContext, err := contextProvider()
+			if err != nil {
+				return err
+			}
+
+			created, err := executer.Scaffold(projectContext.ProjectPath)
+			if err != nil {
+				return err
+			}
+			for _, file := range created {
+				uii.Infoln("Created %s", file)
+			}
+			uii.Infoln("Try it with: SHUTTLE_GOLANG_ACTIONS=true shuttle run hello")
+
+			return nil
+		},
+	}
+}
+
+func newActionsTest(uii *ui.UI, contextProvider contextProvider) *cobra.Command {
+	return &cobra.Command{
+		Use:   "test [-- go test flags]",
+		Short: "Run the go tests of the golang actions of the project and its plan",
+		Example: `  shuttle actions test
+  shuttle actions test -- -run TestDeploy -v`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			projectContext, err := contextProvider()
+			if err != nil {
+				return err
+			}
+
+			ctx, cancel := withSignal(cmd.Context(), uii)
+			defer cancel()
+
+			return executer.Test(ctx, uii, shuttleFilePath(projectContext), &projectContext, args)
+		},
+	}
+}
+
+func newActionsExport(uii *ui.UI, contextProvider contextProvider) *cobra.Command {
+	var platforms []string
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Build prebuilt binaries of the golang actions into actions/dist, to ship them with a plan",
+		Long: `Build prebuilt binaries of the golang actions into actions/dist, to ship them with a plan.
+
+Shuttle uses a prebuilt binary instead of compiling the actions, if it is built from the current sources for the
+platform shuttle runs on. Binaries of previous sources are removed, so commit actions/dist after exporting.`,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			targets := compile.DefaultPlatforms
+			if len(platforms) > 0 {
+				targets = make([]compile.Platform, 0, len(platforms))
+				for _, raw := range platforms {
+					platform, err := compile.ParsePlatform(raw)
+					if err != nil {
+						return err
+					}
+					targets = append(targets, platform)
+				}
+			}
+
+			projectContext, err := contextProvider()
+			if err != nil {
+				return err
+			}
+
+			exported, err := executer.Export(cmd.Context(), uii, shuttleFilePath(projectContext), &projectContext, targets)
+			for _, binary := range exported {
+				uii.Infoln("Exported %s", binary)
+			}
+
+			return err
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&platforms, "platform", nil, "platforms to build for, i.e. linux/amd64 (default linux and darwin on amd64 and arm64)")
+
+	return cmd
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lunarway/shuttle/pkg/executors/golang/cache"
+	"github.com/lunarway/shuttle/pkg/ui"
+)
+
+func newActionsCache(uii *ui.UI) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the user level cache of golang actions binaries",
+		Long: `Manage the user level cache of golang actions binaries.
+
+Binaries are shared between all projects, keyed by a hash of their sources, so
+identical plans are only compiled once. The cache is stored in
+SHUTTLE_GOLANG_ACTIONS_CACHE_DIR, or shuttle/actions in the user cache dir, and
+is limited by SHUTTLE_GOLANG_ACTIONS_CACHE_MAX_SIZE (default 2G).`,
+	}
+
+	cmd.AddCommand(
+		newActionsCacheLs(uii),
+		newActionsCachePrune(uii),
+	)
+
+	return cmd
+}
+
+func newActionsCacheLs(uii *ui.UI) *cobra.Command {
+	return &cobra.Command{
+		Use:          "ls",
+		Short:        "List cached golang actions binaries",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			uii.SetContext(ui.LevelSilent)
+
+			actionsCache, err := cache.Default()
+			if err != nil {
+				return err
+			}
+
+			entries, err := actionsCache.List()
+			if err != nil {
+				return err
+			}
+
+			var total int64
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 3, ' ', 0)
+			fmt.Fprintln(w, "BINARY\tSIZE\tLAST USED")
+			for _, entry := range entries {
+				total += entry.Size
+				fmt.Fprintf(
+					w,
+					"%s\t%s\t%s\n",
+					entry.Key,
+					cache.FormatSize(entry.Size),
+					entry.LastUsed.Format(time.RFC3339),
+				)
+			}
+			if err := w.Flush(); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(
+				cmd.OutOrStdout(),
+				"\n%d binaries using %s of %s in %s\n",
+				len(entries),
+				cache.FormatSize(total),
+				cache.FormatSize(actionsCache.MaxSize()),
+				actionsCache.Dir(),
+			)
+
+			return nil
+		},
+	}
+}
+
+func newActionsCachePrune(uii *ui.UI) *cobra.Command {
+	var (
+		all     bool
+		maxSize string
+	)
+
+	cmd := &cobra.Command{
+		Use:          "prune",
+		Short:        "Remove the least recently used golang actions binaries from the cache",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			uii.SetContext(ui.LevelSilent)
+
+			actionsCache, err := cache.Default()
+			if err != nil {
+				return err
+			}
+
+			limit := actionsCache.MaxSize()
+			if maxSize != "" {
+				limit, err = cache.ParseSize(maxSize)
+				if err != nil {
+					return err
+				}
+			}
+			if all {
+				limit = 0
+			}
+
+			removed, err := actionsCache.Prune(limit)
+			if err != nil {
+				return err
+			}
+
+			var size int64
+			for _, entry := range removed {
+				size += entry.Size
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Removed %d binaries, freeing %s\n", len(removed), cache.FormatSize(size))
+
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&all, "all", false, "remove all cached binaries")
+	cmd.Flags().
+		StringVar(&maxSize, "max-size", "", "shrink the cache to this size, i.e. 500M. Defaults to SHUTTLE_GOLANG_ACTIONS_CACHE_MAX_SIZE")
+
+	return cmd
+}
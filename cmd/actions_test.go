@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActionsWithFailingGolangActions(t *testing.T) {
+	t.Setenv("SHUTTLE_GOLANG_ACTIONS", "true")
+
+	projectPath := t.TempDir()
+	require.NoError(t, os.MkdirAll(path.Join(projectPath, "actions"), 0o755))
+	require.NoError(t, os.WriteFile(path.Join(projectPath, "shuttle.yaml"), []byte("plan: false\n"), 0o644))
+	require.NoError(t, os.WriteFile(path.Join(projectPath, "actions/go.mod"), []byte("module actions\n"), 0o644))
+	require.NoError(t, os.WriteFile(path.Join(projectPath, "actions/build.go"), []byte("package main\n\nfunc Build( {\n"), 0o644))
+
+	testCases := []testCase{
+		{
+			name:  "persistent flags before actions",
+			input: args("-p", projectPath, "-v", "actions", "clean"),
+		},
+		{
+			name:  "help",
+			input: args("-p", projectPath, "help", "actions"),
+		},
+		{
+			name:  "run reports the failure",
+			input: args("-p", projectPath, "run", "build"),
+			err:   errors.New("expected ')', found '{'"),
+		},
+	}
+	executeTestContainsCases(t, testCases)
+}
+
+func TestActionsLs(t *testing.T) {
+	t.Setenv("SHUTTLE_GOLANG_ACTIONS", "true")
+
+	shuttleModule, err := filepath.Abs("..")
+	require.NoError(t, err)
+
+	projectPath := t.TempDir()
+	require.NoError(t, os.MkdirAll(path.Join(projectPath, "actions"), 0o755))
+	require.NoError(t, os.WriteFile(path.Join(projectPath, "shuttle.yaml"), []byte(`plan: false
+scripts:
+  build:
+    actions:
+      - shell: echo build
+`), 0o644))
+	require.NoError(t, os.WriteFile(path.Join(projectPath, "actions/go.mod"), []byte(
+		"module actions\n\ngo 1.18\n\nreplace github.com/lunarway/shuttle => "+shuttleModule+"\n",
+	), 0o644))
+	require.NoError(t, os.WriteFile(path.Join(projectPath, "actions/build.go"), []byte(`package main
+
+import "context"
+
+func Build(ctx context.Context) error {
+	return nil
+}
+`), 0o644))
+
+	testCases := []testCase{
+		{
+			name:  "action replacing a script",
+			input: args("-p", projectPath, "actions", "ls"),
+			stdoutput: `ACTION   LOCAL   PLAN   USED
+build    yes     -      local
+`,
+		},
+	}
+	executeTestCasesWithCustomAssertion(t, testCases, func(t *testing.T, tc testCase, stdout, stderr string) {
+		assert.Equal(t, tc.stdoutput, stdout, "std output not as expected")
+	})
+}
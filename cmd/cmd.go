@@ -71,7 +71,9 @@ __shuttle_custom_func() {
 }
 `
 
-func newRoot(uii *ui.UI) (*cobra.Command, contextProvider, repositoryContext) {
+// newRoot returns the root command and the providers of the project context. The config provider leaves out golang
+// actions, as they may fail to compile
+func newRoot(uii *ui.UI) (*cobra.Command, contextProvider, contextProvider, repositoryContext) {
 	telemetry.Setup()
 
 	var (
@@ -115,14 +117,18 @@ If none of above is used, then the argument will expect a full plan spec.`)
 	rootCmd.PersistentFlags().BoolVarP(&verboseFlag, "verbose", "v", false, "Print verbose output")
 
 	ctxProvider := func() (config.ShuttleProjectContext, error) {
-		return getProjectContext(rootCmd, uii, projectPath, clean, plan, skipGitPlanPulling)
+		return getProjectContext(rootCmd, uii, projectPath, clean, plan, skipGitPlanPulling, true)
+	}
+
+	configProvider := func() (config.ShuttleProjectContext, error) {
+		return getProjectContext(rootCmd, uii, projectPath, clean, plan, skipGitPlanPulling, false)
 	}
 
 	repositoryCtxProvider := func() bool {
 		return getRepositoryContext(projectPath)
 	}
 
-	return rootCmd, ctxProvider, repositoryCtxProvider
+	return rootCmd, ctxProvider, configProvider, repositoryCtxProvider
 }
 
 func Execute(stdout, stderr io.Writer) {
@@ -150,7 +156,7 @@ func Execute(stdout, stderr io.Writer) {
 func initializedRootFromArgs(stdout, stderr io.Writer, args []string) (*cobra.Command, *ui.UI, error) {
 	uii := ui.Create(stdout, stderr)
 
-	rootCmd, ctxProvider, isInRepoContext := newRoot(uii)
+	rootCmd, ctxProvider, configProvider, isInRepoContext := newRoot(uii)
 	rootCmd.SetOut(stdout)
 	rootCmd.SetErr(stderr)
 
@@ -162,7 +168,12 @@ func initializedRootFromArgs(stdout, stderr io.Writer, args []string) (*cobra.Co
 	if isInRepoContext() {
 		runCmd, err := newRun(uii, ctxProvider)
 		if err != nil {
-			return nil, nil, err
+			// shuttle actions builds and diagnoses golang actions itself, so it has to work when they fail to compile.
+			// If the project loads without them, the error is left to the commands using the golang actions
+			if _, configErr := configProvider(); configErr != nil {
+				return nil, nil, err
+			}
+			runCmd = newFailedRun(err)
 		}
 		rootCmd.AddCommand(
 			newDocumentation(uii, ctxProvider),
@@ -174,10 +185,13 @@ func initializedRootFromArgs(stdout, stderr io.Writer, args []string) (*cobra.Co
 			newPlan(uii, ctxProvider),
 			runCmd,
 			newPrepare(uii, ctxProvider),
+			newSet(uii, configProvider),
 			newTemplate(uii, ctxProvider),
+			newUnset(uii, configProvider),
 			newVersion(uii),
 			newConfig(uii, ctxProvider),
 			newTelemetry(uii),
+			newActions(uii, configProvider),
 		)
 
 		return rootCmd, uii, nil
@@ -189,6 +203,7 @@ func initializedRootFromArgs(stdout, stderr io.Writer, args []string) (*cobra.Co
 			newTelemetry(uii),
 			newHas(uii, ctxProvider),
 			newConfig(uii, ctxProvider),
+			newActions(uii, configProvider),
 		)
 
 		return rootCmd, uii, nil
@@ -209,6 +224,7 @@ func getProjectContext(
 	clean bool,
 	plan string,
 	skipGitPlanPulling bool,
+	golangActions bool,
 ) (config.ShuttleProjectContext, error) {
 	dir, err := os.Getwd()
 	if err != nil {
@@ -243,6 +259,9 @@ func getProjectContext(
 	if err != nil {
 		return config.ShuttleProjectContext{}, err
 	}
+	if !golangActions {
+		return c, nil
+	}
 
 	ctx := stdcontext.Background()
 	taskActions, err := executer.List(
@@ -260,13 +279,21 @@ func getProjectContext(
 
 		for _, taskArg := range action.Args {
 			args = append(args, config.ShuttleScriptArgs{
-				Name:     taskArg.Name,
-				Required: true,
+				Name:        taskArg.Name,
+				Required:    !taskArg.Optional,
+				Description: taskArg.Description,
+				Type:        taskArg.Type,
+				Default:     taskArg.Default,
 			})
 		}
 
+		description := action.Description
+		if description == "" {
+			description = name
+		}
+
 		c.Scripts[name] = config.ShuttlePlanScript{
-			Description: name,
+			Description: description,
 			Actions: []config.ShuttleAction{
 				{
 					Task: name,
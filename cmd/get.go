@@ -1,11 +1,15 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"strconv"
 	"strings"
 
 	"gopkg.in/yaml.v2"
 
+	"github.com/lunarway/shuttle/pkg/config"
 	"github.com/lunarway/shuttle/pkg/errors"
 	"github.com/lunarway/shuttle/pkg/templates"
 	"github.com/lunarway/shuttle/pkg/ui"
@@ -13,51 +17,326 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// Output formats of shuttle get
+const (
+	getOutputYAML = "yaml"
+	getOutputJSON = "json"
+	getOutputEnv  = "env"
+	getOutputRaw  = "raw"
+	getOutputFlat = "flat"
+)
+
+// Key cases of --output env
+const (
+	envCaseUpper    = "upper"
+	envCasePreserve = "preserve"
+	envCaseLower    = "lower"
+)
+
+// getValue is the value of a variable path passed to shuttle get
+type getValue struct {
+	path     string
+	elements []templates.PathElement
+	value    interface{}
+	found    bool
+}
+
 func newGet(uii *ui.UI, contextProvider contextProvider) *cobra.Command {
-	var getFlagTemplate string
+	var getFlagTemplate, output, envPrefix, envCase string
+	var values config.Values
 	getCmd := &cobra.Command{
-		Use:   "get [variable]",
+		Use:   "get [variable...]",
 		Short: "Get a variable value",
-		Args:  cobra.ExactArgs(1),
+		Long: `Get a variable value.
+
+Variables are separated by dots, i.e. docker.image. Lists are indexed with services.0.name or services[0].name, and
+negative indexes count from the end, i.e. ports[-1]. Wildcards like services.*.name return lists, and keys with dots
+are quoted, i.e. annotations."lunar.tech/owner".
+
+--output selects the format of the values:
+
+  yaml  the value as yaml (default). Several variables are printed as a map by variable
+  json  the value as json. Several variables are printed as an object by variable
+  raw   a line per variable with strings unquoted, and lists and maps as json. Missing variables print empty lines
+  flat  a line of path=value for every leaf, i.e. docker.image.tag=v1
+  env   a line of export KEY=value for every leaf with shell quoted values, i.e. export DOCKER_IMAGE_TAG=v1. Keys are
+        named by --env-prefix and --env-case`,
+		Example: `  shuttle get docker.image
+  shuttle get docker.image docker.tag --output raw
+  eval "$(shuttle get docker --output env --env-prefix APP_)"`,
+		Args: cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			uii.SetContext(ui.LevelError)
 
+			switch output {
+			case getOutputYAML, getOutputJSON, getOutputEnv, getOutputRaw, getOutputFlat:
+			default:
+				return errors.NewExitCode(2, "Invalid --output '%s', expected yaml, json, env, raw or flat", output)
+			}
+			switch envCase {
+			case envCaseUpper, envCasePreserve, envCaseLower:
+			default:
+				return errors.NewExitCode(2, "Invalid --env-case '%s', expected upper, lower or preserve", envCase)
+			}
+			if getFlagTemplate != "" && (len(args) > 1 || cmd.Flags().Changed("output")) {
+				return errors.NewExitCode(2, "--template only supports a single variable and no --output")
+			}
+
 			context, err := contextProvider()
 			if err != nil {
 				return err
 			}
 
-			path := args[0]
-			var templ string
-			if getFlagTemplate != "" {
-				templ = getFlagTemplate
+			vars, err := values.Apply(context.Config.Variables)
+			if err != nil {
+				return err
 			}
-			value := templates.TmplGet(path, context.Config.Variables)
-			if templ != "" {
-				err := ui.Template(cmd.OutOrStdout(), "get", templ, value)
+			got := make([]getValue, 0, len(args))
+			for _, path := range args {
+				value, found, err := templates.Lookup(path, vars)
 				if err != nil {
-					return err
+					return errors.NewExitCode(2, "Invalid variable path: %s", err)
 				}
+				// the path is valid as Lookup parsed it
+				elements, _ := templates.ParsePath(path)
+				got = append(got, getValue{path: path, elements: elements, value: value, found: found})
+			}
 
-				return nil
+			w := cmd.OutOrStdout()
+			if getFlagTemplate != "" {
+				return ui.Template(w, "get", getFlagTemplate, got[0].value)
 			}
-			switch value.(type) {
-			case nil:
-				// print nothing
-			default:
-				x, err := yaml.Marshal(value)
-				if err != nil {
-					return errors.NewExitCode(9, "Could not yaml encode value '%s'\nError: %s", value, err)
+
+			switch output {
+			case getOutputJSON:
+				return printGetJSON(w, got)
+			case getOutputRaw:
+				for _, v := range got {
+					raw, err := rawValue(v.value)
+					if err != nil {
+						return err
+					}
+					fmt.Fprintln(w, raw)
 				}
-				fmt.Fprint(cmd.OutOrStdout(), strings.TrimRight(string(x), "\n"))
+				return nil
+			case getOutputFlat:
+				return printGetLeaves(got, func(v getValue, elements []templates.PathElement, leaf string) {
+					key := v.path
+					for _, element := range elements {
+						key += "." + element.String()
+					}
+					fmt.Fprintf(w, "%s=%s\n", key, leaf)
+				})
+			case getOutputEnv:
+				return printGetLeaves(got, func(v getValue, elements []templates.PathElement, leaf string) {
+					key := envKey(envPrefix, envCase, append(v.elements, elements...))
+					fmt.Fprintf(w, "export %s=%s\n", key, shellQuote(leaf))
+				})
+			default:
+				return printGetYAML(w, got)
 			}
-
-			return nil
 		},
 	}
 
 	getCmd.Flags().
 		StringVar(&getFlagTemplate, "template", "", "Template string to use. See --help for details.")
+	getCmd.Flags().
+		StringVarP(&output, "output", "o", getOutputYAML, "Output format, one of yaml, json, env, raw or flat")
+	getCmd.Flags().
+		StringVar(&envPrefix, "env-prefix", "", "Prefix of the keys of --output env")
+	getCmd.Flags().
+		StringVar(&envCase, "env-case", envCaseUpper, "Case of the keys of --output env, one of upper, lower or preserve")
+	addValuesFlags(getCmd.Flags(), &values)
 
 	return getCmd
 }
+
+func printGetYAML(w io.Writer, got []getValue) error {
+	var value interface{}
+	if len(got) == 1 {
+		// print nothing for missing variables
+		if got[0].value == nil {
+			return nil
+		}
+		value = got[0].value
+	} else {
+		byPath := make(yaml.MapSlice, 0, len(got))
+		for _, v := range got {
+			byPath = append(byPath, yaml.MapItem{Key: v.path, Value: v.value})
+		}
+		value = byPath
+	}
+
+	x, err := yaml.Marshal(value)
+	if err != nil {
+		return errors.NewExitCode(9, "Could not yaml encode value '%s'\nError: %s", value, err)
+	}
+	fmt.Fprint(w, strings.TrimRight(string(x), "\n"))
+	return nil
+}
+
+func printGetJSON(w io.Writer, got []getValue) error {
+	var value interface{}
+	if len(got) == 1 {
+		value = jsonValue(got[0].value)
+	} else {
+		byPath := make(map[string]interface{}, len(got))
+		for _, v := range got {
+			byPath[v.path] = jsonValue(v.value)
+		}
+		value = byPath
+	}
+
+	x, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return errors.NewExitCode(9, "Could not json encode value '%s'\nError: %s", value, err)
+	}
+	fmt.Fprintln(w, string(x))
+	return nil
+}
+
+// printGetLeaves calls print with the raw value of every leaf of the variables found, and the path of the leaf
+// relative to the variable
+func printGetLeaves(got []getValue, print func(v getValue, elements []templates.PathElement, leaf string)) error {
+	for _, v := range got {
+		if !v.found {
+			continue
+		}
+		var err error
+		walkLeaves(v.value, nil, func(elements []templates.PathElement, leaf interface{}) {
+			if err != nil {
+				return
+			}
+			var raw string
+			raw, err = rawValue(leaf)
+			if err == nil {
+				print(v, elements, raw)
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkLeaves calls visit for every value in value that isn't a non-empty map or list, with maps ordered by key
+func walkLeaves(value interface{}, elements []templates.PathElement, visit func([]templates.PathElement, interface{})) {
+	switch v := value.(type) {
+	case []interface{}:
+		if len(v) == 0 {
+			break
+		}
+		for i, item := range v {
+			walkLeaves(item, appendElement(elements, templates.PathElement{Key: strconv.Itoa(i), Index: i, IsIndex: true}), visit)
+		}
+		return
+	case map[interface{}]interface{}, map[string]interface{}:
+		pairs := templates.ObjectPairs(v)
+		if len(pairs) == 0 {
+			break
+		}
+		for _, pair := range pairs {
+			walkLeaves(pair.Value, appendElement(elements, templates.PathElement{Key: pair.Key}), visit)
+		}
+		return
+	}
+
+	visit(elements, value)
+}
+
+// appendElement appends element to a copy of elements, so the elements of siblings don't share the same array
+func appendElement(elements []templates.PathElement, element templates.PathElement) []templates.PathElement {
+	result := make([]templates.PathElement, 0, len(elements)+1)
+	result = append(result, elements...)
+	return append(result, element)
+}
+
+// rawValue formats scalars as is, and lists and maps as json
+func rawValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool, int, int64, uint64, float64:
+		return fmt.Sprint(v), nil
+	default:
+		x, err := json.Marshal(jsonValue(v))
+		if err != nil {
+			return "", errors.NewExitCode(9, "Could not json encode value '%s'\nError: %s", value, err)
+		}
+		return string(x), nil
+	}
+}
+
+// jsonValue converts the maps decoded by yaml to maps with string keys, which can be encoded as json
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[fmt.Sprint(key)] = jsonValue(item)
+		}
+		return m
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[key] = jsonValue(item)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(v))
+		for i, item := range v {
+			l[i] = jsonValue(item)
+		}
+		return l
+	default:
+		return v
+	}
+}
+
+// envKey names the environment variable of a path, i.e. APP_DOCKER_IMAGE for docker.image with the prefix APP_.
+// Characters other than letters, digits and _ are replaced by _
+func envKey(prefix, keyCase string, elements []templates.PathElement) string {
+	names := make([]string, 0, len(elements))
+	for _, element := range elements {
+		switch {
+		case element.Wildcard:
+			names = append(names, "_")
+		case element.Key == "":
+			names = append(names, strconv.Itoa(element.Index))
+		default:
+			names = append(names, element.Key)
+		}
+	}
+
+	key := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, strings.Join(names, "_"))
+	switch keyCase {
+	case envCaseUpper:
+		key = strings.ToUpper(key)
+	case envCaseLower:
+		key = strings.ToLower(key)
+	}
+
+	key = prefix + key
+	if key != "" && key[0] >= '0' && key[0] <= '9' {
+		key = "_" + key
+	}
+	return key
+}
+
+// shellQuote quotes s for sh, unless it only has characters that are safe unquoted
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:,@%+=") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
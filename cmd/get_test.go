@@ -1,7 +1,10 @@
 package cmd
 
 import (
+	"errors"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestGet(t *testing.T) {
@@ -41,6 +44,116 @@ func TestGet(t *testing.T) {
 			erroutput: "",
 			err:       nil,
 		},
+		{
+			name: "values and set",
+			input: args(
+				"-p",
+				"testdata/project",
+				"get",
+				"nested",
+				"-f",
+				"testdata/project/values/prod.yaml",
+				"--set",
+				"nested.sub.field=qux",
+			),
+			stdoutput: "sub:\n  field: qux\nvar: bar",
+			erroutput: "",
+			err:       nil,
+		},
+		{
+			name:      "negative list index",
+			input:     args("-p", "testdata/project", "get", "services[-1].name"),
+			stdoutput: "worker",
+			erroutput: "",
+			err:       nil,
+		},
+		{
+			name:      "set list element",
+			input:     args("-p", "testdata/project", "get", "services[-1].name", "--set", "services[-1].name=jobs"),
+			stdoutput: "jobs",
+			erroutput: "",
+			err:       nil,
+		},
+		{
+			name: "set keeps versions as strings",
+			input: args(
+				"-p",
+				"testdata/project",
+				"get",
+				"nested.var",
+				"nested.flag",
+				"--output",
+				"json",
+				"--set",
+				"nested.var=1.20",
+				"--set-string",
+				"nested.flag=true",
+			),
+			stdoutput: "{\n  \"nested.flag\": \"true\",\n  \"nested.var\": \"1.20\"\n}\n",
+			erroutput: "",
+			err:       nil,
+		},
+		{
+			name:      "wildcard",
+			input:     args("-p", "testdata/project", "get", "services.*.name"),
+			stdoutput: "- api\n- worker",
+			erroutput: "",
+			err:       nil,
+		},
+		{
+			name:      "json",
+			input:     args("-p", "testdata/project", "get", "nested", "--output", "json"),
+			stdoutput: "{\n  \"sub\": {\n    \"field\": \"baz\"\n  },\n  \"var\": \"foo\"\n}\n",
+			erroutput: "",
+			err:       nil,
+		},
+		{
+			name:      "yaml with several variables",
+			input:     args("-p", "testdata/project", "get", "service", "nested.var", "missing"),
+			stdoutput: "service: shuttle\nnested.var: foo\nmissing: null",
+			erroutput: "",
+			err:       nil,
+		},
+		{
+			name:      "raw with several variables",
+			input:     args("-p", "testdata/project", "get", "service", "missing", "services[0].ports", "-o", "raw"),
+			stdoutput: "shuttle\n\n[80,443]\n",
+			erroutput: "",
+			err:       nil,
+		},
+		{
+			name:      "flat",
+			input:     args("-p", "testdata/project", "get", "nested", "services[0]", "-o", "flat"),
+			stdoutput: "nested.sub.field=baz\nnested.var=foo\nservices[0].name=api\nservices[0].ports.0=80\nservices[0].ports.1=443\n",
+			erroutput: "",
+			err:       nil,
+		},
+		{
+			name: "env",
+			input: args(
+				"-p",
+				"testdata/project",
+				"get",
+				"nested",
+				"message",
+				"-o",
+				"env",
+				"--env-prefix",
+				"APP_",
+				"--set",
+				"message=it's ready",
+			),
+			stdoutput: "export APP_NESTED_SUB_FIELD=baz\nexport APP_NESTED_VAR=foo\nexport APP_MESSAGE='it'\\''s ready'\n",
+			erroutput: "",
+			err:       nil,
+		},
+		{
+			name:      "env with lower case keys",
+			input:     args("-p", "testdata/project", "get", "services.*.name", "-o", "env", "--env-case", "lower"),
+			stdoutput: "export services___name_0=api\nexport services___name_1=worker\n",
+			erroutput: "",
+			err:       nil,
+		},
 		{
 			name:      "bool",
 			input:     args("-p", "testdata/project", "get", "boolVar"),
@@ -51,3 +164,21 @@ func TestGet(t *testing.T) {
 	}
 	executeTestCases(t, testCases)
 }
+
+func TestGetUsageErrors(t *testing.T) {
+	testCases := []testCase{
+		{
+			name:  "unknown output",
+			input: args("-p", "testdata/project", "get", "service", "-o", "xml"),
+			err:   errors.New("exit code 2 - Invalid --output 'xml', expected yaml, json, env, raw or flat"),
+		},
+		{
+			name:  "empty element",
+			input: args("-p", "testdata/project", "get", "services..x"),
+			err:   errors.New("exit code 2 - Invalid variable path: path services..x: empty element at position 9"),
+		},
+	}
+	executeTestCasesWithCustomAssertion(t, testCases, func(t *testing.T, tc testCase, stdout, stderr string) {
+		assert.Contains(t, stdout, "Usage:", "usage not printed")
+	})
+}
@@ -37,7 +37,7 @@ func newHas(uii *ui.UI, contextProvider contextProvider) *cobra.Command {
 			if lookupInScripts {
 				_, found = context.Scripts[variable]
 			} else {
-				found = templates.TmplGet(variable, context.Config.Variables) != nil
+				found = hasVariable(variable, context.Config.Variables)
 			}
 
 			if outputAsStdout {
@@ -64,3 +64,22 @@ func newHas(uii *ui.UI, contextProvider contextProvider) *cobra.Command {
 
 	return hasCmd
 }
+
+// hasVariable returns whether the variable path is set to a value other than null. Invalid paths can't be set, so they
+// aren't found either, and wildcards are only found if they match at least one value
+func hasVariable(variable string, vars interface{}) bool {
+	value, found, err := templates.Lookup(variable, vars)
+	if err != nil || !found || value == nil {
+		return false
+	}
+
+	// the path is valid as Lookup parsed it
+	elements, _ := templates.ParsePath(variable)
+	for _, element := range elements {
+		if element.Wildcard {
+			matches, _ := value.([]interface{})
+			return len(matches) > 0
+		}
+	}
+	return true
+}
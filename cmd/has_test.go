@@ -29,6 +29,41 @@ func TestHas(t *testing.T) {
 			erroutput: "",
 			err:       nil,
 		},
+		{
+			name:      "list index",
+			input:     args("-p", "testdata/project", "has", "services[0].ports[1]"),
+			stdoutput: "",
+			erroutput: "",
+			err:       nil,
+		},
+		{
+			name:      "list index out of range",
+			input:     args("-p", "testdata/project", "has", "services[0].ports[2]"),
+			stdoutput: "",
+			erroutput: "",
+			err:       errors.New("exit code 1 - "),
+		},
+		{
+			name:      "wildcard",
+			input:     args("-p", "testdata/project", "has", "services.*.ports"),
+			stdoutput: "",
+			erroutput: "",
+			err:       nil,
+		},
+		{
+			name:      "wildcard without matches",
+			input:     args("-p", "testdata/project", "has", "services.*.missing"),
+			stdoutput: "",
+			erroutput: "",
+			err:       errors.New("exit code 1 - "),
+		},
+		{
+			name:      "invalid path",
+			input:     args("-p", "testdata/project", "has", "nested."),
+			stdoutput: "",
+			erroutput: "",
+			err:       errors.New("exit code 1 - "),
+		},
 		{
 			name:      "not existing",
 			input:     args("-p", "testdata/project", "has", "unknown"),
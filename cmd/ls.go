@@ -1,6 +1,9 @@
 package cmd
 
 import (
+	"sort"
+	"strings"
+
 	"github.com/lunarway/shuttle/pkg/config"
 	"github.com/lunarway/shuttle/pkg/ui"
 	"github.com/spf13/cobra"
@@ -9,14 +12,27 @@ import (
 const lsDefaultTempl = `
 {{- $max := .Max -}}
 Available Scripts:
+{{- range .Groups}}
+{{- if .Namespace}}
+
+{{.Namespace}}:
+{{- end}}
 {{- range $key, $value := .Scripts}}
   {{rightPad $key $max }} {{upperFirst $value.Description}}
 {{- end}}
+{{- end}}
 `
 
 type templData struct {
 	Scripts map[string]config.ShuttlePlanScript
-	Max     int
+	// Groups are the scripts grouped by namespace, with the scripts without a namespace first
+	Groups []scriptGroup
+	Max    int
+}
+
+type scriptGroup struct {
+	Namespace string
+	Scripts   map[string]config.ShuttlePlanScript
 }
 
 func newLs(uii *ui.UI, contextProvider contextProvider) *cobra.Command {
@@ -40,6 +56,7 @@ func newLs(uii *ui.UI, contextProvider contextProvider) *cobra.Command {
 			}
 			err = ui.Template(cmd.OutOrStdout(), "ls", templ, templData{
 				Scripts: context.Scripts,
+				Groups:  groupScripts(context.Scripts),
 				Max:     calculateRightPadForKeys(context.Scripts),
 			})
 			if err != nil {
@@ -65,3 +82,37 @@ func calculateRightPadForKeys(m map[string]config.ShuttlePlanScript) int {
 	}
 	return max + 2
 }
+
+// scriptNamespace is the part of a script name before the last colon, i.e. db for db:migrate
+func scriptNamespace(script string) string {
+	i := strings.LastIndex(script, ":")
+	if i == -1 {
+		return ""
+	}
+
+	return script[:i]
+}
+
+func groupScripts(scripts map[string]config.ShuttlePlanScript) []scriptGroup {
+	byNamespace := make(map[string]map[string]config.ShuttlePlanScript)
+	for name, script := range scripts {
+		namespace := scriptNamespace(name)
+		if byNamespace[namespace] == nil {
+			byNamespace[namespace] = make(map[string]config.ShuttlePlanScript)
+		}
+		byNamespace[namespace][name] = script
+	}
+
+	groups := make([]scriptGroup, 0, len(byNamespace))
+	for namespace, scripts := range byNamespace {
+		groups = append(groups, scriptGroup{
+			Namespace: namespace,
+			Scripts:   scripts,
+		})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Namespace < groups[j].Namespace
+	})
+
+	return groups
+}
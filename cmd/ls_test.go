@@ -23,6 +23,13 @@ func TestLs(t *testing.T) {
 			erroutput: "",
 			err:       nil,
 		},
+		{
+			name:      "list namespaced scripts",
+			input:     args("-p", "testdata/project-namespaced", "ls"),
+			stdoutput: "Available Scripts:\n  build             Build the service\n\ndb:\n  db:migrate        Migrate the database\n\ndb:schema:\n  db:schema:apply   \n",
+			erroutput: "",
+			err:       nil,
+		},
 	}
 	executeTestCases(t, testCases)
 }
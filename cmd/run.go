@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"slices"
 	"sort"
 	"strings"
+	"syscall"
 
 	"github.com/AlecAivazis/survey/v2"
 	"github.com/iancoleman/strcase"
@@ -36,6 +38,17 @@ func newNoContextRun() *cobra.Command {
 	return runCmd
 }
 
+// newFailedRun returns the run command of a project whose scripts can't be loaded, which fails with err
+func newFailedRun(err error) *cobra.Command {
+	runCmd := newNoopRun()
+
+	runCmd.RunE = func(cmd *cobra.Command, args []string) error {
+		return err
+	}
+
+	return runCmd
+}
+
 func newRun(uii *ui.UI, contextProvider contextProvider) (*cobra.Command, error) {
 	var (
 		flagTemplate   string
@@ -72,6 +85,45 @@ func newRun(uii *ui.UI, contextProvider contextProvider) (*cobra.Command, error)
 		)
 	}
 
+	// Namespaced scripts, i.e. db:migrate, can also be run as nested commands, i.e. shuttle run db migrate.
+	// Scripts are sorted so collisions between namespaces and scripts are resolved the same way every time
+	scripts := make([]string, 0, len(context.Scripts))
+	for script := range context.Scripts {
+		scripts = append(scripts, script)
+	}
+	sort.Strings(scripts)
+	for _, script := range scripts {
+		value := context.Scripts[script]
+		parts := strings.Split(script, ":")
+		if len(parts) == 1 || slices.Contains(parts, "") {
+			continue
+		}
+
+		parent := runCmd
+		for i, part := range parts[:len(parts)-1] {
+			parent = namespaceCommand(parent, part, strings.Join(parts[:i+1], ":"))
+			if parent == nil {
+				break
+			}
+		}
+		if parent == nil || findSubCommand(parent, parts[len(parts)-1]) != nil {
+			// the namespace collides with a script, which still can be run by its full name
+			continue
+		}
+
+		nestedCmd := newRunSubCommand(
+			uii,
+			context,
+			script,
+			value,
+			executorRegistry,
+			&interactiveArg,
+			&validateArgs,
+		)
+		nestedCmd.Use = parts[len(parts)-1]
+		parent.AddCommand(nestedCmd)
+	}
+
 	runCmd.PersistentFlags().
 		StringVar(&flagTemplate, "template", "", "Template string to use. The template format is golang templates [http://golang.org/pkg/text/template/#pkg-overview].")
 	runCmd.PersistentFlags().
@@ -81,6 +133,36 @@ func newRun(uii *ui.UI, contextProvider contextProvider) (*cobra.Command, error)
 	return runCmd, nil
 }
 
+// namespaceCommand returns the command grouping the scripts of namespace under parent, creating it if needed.
+// It returns nil if a script already uses the name
+func namespaceCommand(parent *cobra.Command, name string, namespace string) *cobra.Command {
+	if existing := findSubCommand(parent, name); existing != nil {
+		if existing.Runnable() {
+			return nil
+		}
+		return existing
+	}
+
+	cmd := &cobra.Command{
+		Use:          name,
+		Short:        fmt.Sprintf("Scripts in the %s namespace", namespace),
+		SilenceUsage: true,
+	}
+	parent.AddCommand(cmd)
+
+	return cmd
+}
+
+func findSubCommand(parent *cobra.Command, name string) *cobra.Command {
+	for _, cmd := range parent.Commands() {
+		if cmd.Name() == name {
+			return cmd
+		}
+	}
+
+	return nil
+}
+
 func newRunSubCommand(
 	uii *ui.UI,
 	context config.ShuttleProjectContext,
@@ -105,11 +187,12 @@ func newRunSubCommand(
 	}
 
 	// Legacy key=value pairs into standard args that cobra can understand
-	applyLegacyArgs := func(args []string, inputArgs map[string]*string) {
+	applyLegacyArgs := func(args []string, inputArgs map[string]*string, providedArgs map[string]bool) {
 		for _, inputArg := range args {
 			key, value, ok := parseKeyValuePair(inputArg)
 			if ok {
 				inputArgs[key] = &value
+				providedArgs[key] = true
 			}
 		}
 	}
@@ -139,7 +222,7 @@ func newRunSubCommand(
 	}
 
 	// Decide whether to fall back on prompt or give a hard error
-	validateInputArgs := func(value config.ShuttlePlanScript, inputArgs map[string]*string) error {
+	validateInputArgs := func(value config.ShuttlePlanScript, inputArgs map[string]*string, providedArgs map[string]bool) error {
 		for _, arg := range value.Args {
 			if !arg.Required {
 				continue
@@ -154,6 +237,7 @@ func newRunSubCommand(
 				}
 				if output != "" {
 					inputArgs[arg.Name] = &output
+					providedArgs[arg.Name] = true
 				}
 
 			} else if *inputArgs[arg.Name] == "" && arg.Required && *validateArgs {
@@ -173,7 +257,8 @@ func newRunSubCommand(
 	inputArgs := make(map[string]*string, 0)
 	for _, arg := range value.Args {
 		arg := arg
-		inputArgs[arg.Name] = new(string)
+		defaultValue := arg.Default
+		inputArgs[arg.Name] = &defaultValue
 	}
 
 	cmd := &cobra.Command{
@@ -190,8 +275,15 @@ func newRunSubCommand(
 			ctx, _, traceError, traceEnd := trace(ctx, script, args)
 			defer traceEnd()
 
-			applyLegacyArgs(args, inputArgs)
-			if err := validateInputArgs(value, inputArgs); err != nil {
+			// args which aren't provided hold their default, see executors.ScriptExecutionContext
+			providedArgs := make(map[string]bool, len(inputArgs))
+			for _, arg := range value.Args {
+				if cmd.Flags().Changed(argName(arg.Name)) {
+					providedArgs[arg.Name] = true
+				}
+			}
+			applyLegacyArgs(args, inputArgs, providedArgs)
+			if err := validateInputArgs(value, inputArgs, providedArgs); err != nil {
 				return err
 			}
 
@@ -202,7 +294,7 @@ func newRunSubCommand(
 				actualArgs[k] = *v
 			}
 
-			err := executorRegistry.Execute(ctx, context, script, actualArgs, *validateArgs)
+			err := executorRegistry.Execute(ctx, context, script, actualArgs, providedArgs, *validateArgs)
 			if err != nil {
 				traceError(err)
 				return err
@@ -218,7 +310,7 @@ func newRunSubCommand(
 
 	for _, arg := range value.Args {
 		arg := arg
-		cmd.Flags().StringVar(inputArgs[arg.Name], argName(arg.Name), "", arg.Description)
+		cmd.Flags().Var(&scriptArgValue{value: inputArgs[arg.Name], arg: arg}, argName(arg.Name), arg.Description)
 		// Bool-typed args may be passed without an explicit value, e.g.
 		// "--silent" is treated as "--silent=true".
 		if arg.Type == "bool" {
@@ -229,29 +321,83 @@ func newRunSubCommand(
 	return cmd
 }
 
+// scriptArgValue stores the raw value of a script argument, while validating it
+// against the arguments type
+type scriptArgValue struct {
+	value *string
+	arg   config.ShuttleScriptArgs
+	set   bool
+}
+
+func (v *scriptArgValue) String() string {
+	if v.value == nil {
+		return ""
+	}
+	return *v.value
+}
+
+func (v *scriptArgValue) Set(s string) error {
+	if err := v.arg.ValidateValue(s); err != nil {
+		return err
+	}
+
+	// repeated slice flags are accumulated like --tag a --tag b
+	if v.arg.Type == "stringSlice" && v.set {
+		s = *v.value + "," + s
+	}
+	*v.value = s
+	v.set = true
+
+	return nil
+}
+
+func (v *scriptArgValue) Type() string {
+	if v.arg.Type == "" {
+		return "string"
+	}
+	return v.arg.Type
+}
+
 // withSignal returns a copy of parent with a new Done channel. The returned
 // context's Done channel is closed when the returned cancel function is called,
-// if the parent context's Done channel is closed, if a SIGINT signal is
-// catched, whichever happens first.
+// if the parent context's Done channel is closed, if a SIGINT or SIGTERM signal
+// is catched, whichever happens first.
+//
+// The cause of a context cancelled by a signal carries the signal, so executors
+// are able to forward it.
 //
 // Canceling this context releases resources associated with it, so code should
 // call cancel as soon as the operations running in this Context complete.
 func withSignal(parent stdcontext.Context, uii *ui.UI) (stdcontext.Context, func()) {
-	parent, cancel := stdcontext.WithCancel(parent)
+	parent, cancel := stdcontext.WithCancelCause(parent)
 	c := make(chan os.Signal, 1)
-	signal.Notify(c, os.Interrupt)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 
 	go func() {
 		select {
 		case s := <-c:
 			uii.Infoln("Received %v signal...", s)
-			cancel()
+			cancel(signalError{signal: s})
 		case <-parent.Done():
 		}
 	}()
 
 	return parent, func() {
 		signal.Stop(c)
-		cancel()
+		cancel(nil)
 	}
 }
+
+// signalError is the cause of a context cancelled by withSignal
+type signalError struct {
+	signal os.Signal
+}
+
+func (e signalError) Error() string {
+	return fmt.Sprintf("received %v signal", e.signal)
+}
+
+// Signal returns the signal received by shuttle
+func (e signalError) Signal() os.Signal {
+	return e.signal
+}
@@ -14,6 +14,20 @@ func TestRun(t *testing.T) {
 	}
 
 	testCases := []testCase{
+		{
+			name:      "namespaced script",
+			input:     args("-p", "testdata/project-namespaced", "run", "db:migrate", "--steps", "2"),
+			stdoutput: "migrate 2\n",
+			erroutput: "",
+			err:       nil,
+		},
+		{
+			name:      "namespaced script as nested command",
+			input:     args("-p", "testdata/project-namespaced", "run", "db", "schema", "apply"),
+			stdoutput: "apply\n",
+			erroutput: "",
+			err:       nil,
+		},
 		{
 			name:      "std out echo",
 			input:     args("-p", "testdata/project", "run", "hello_stdout"),
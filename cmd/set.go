@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/cobra"
+
+	"github.com/lunarway/shuttle/pkg/config"
+	"github.com/lunarway/shuttle/pkg/errors"
+	"github.com/lunarway/shuttle/pkg/ui"
+)
+
+func newSet(uii *ui.UI, contextProvider contextProvider) *cobra.Command {
+	var dryRun, asString bool
+	setCmd := &cobra.Command{
+		Use:   "set [variable] [value]",
+		Short: "Set a variable in shuttle.yaml",
+		Long: `Set a variable in the vars of shuttle.yaml.
+
+Variables use the paths of shuttle get, except for wildcards, and maps missing on the path are created. Values are
+typed like in yaml, so 3 is a number, true a boolean and [a, b] a list, while anything else is a string. Values are
+only typed if yaml writes the typed value the same way, so versions like 1.20 and codes like 010 or no are kept as
+strings. Use --string to keep any value a string.
+
+Only the lines of the variable are changed, so comments and formatting of the rest of shuttle.yaml are kept.`,
+		Example: `  shuttle set docker.replicas 3
+  shuttle set services[0].ports '[80, 443]' --dry-run
+  shuttle set docker.tag true --string`,
+		Args:         cobra.ExactArgs(2),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return editShuttleFile(cmd.OutOrStdout(), uii, contextProvider, dryRun, func(content []byte) ([]byte, error) {
+				var value interface{} = args[1]
+				if !asString {
+					value = config.ParseValue(args[1])
+				}
+				updated, err := config.SetVar(content, args[0], value)
+				if err != nil {
+					return nil, errors.NewExitCode(2, "Could not set variable: %s", err)
+				}
+				return updated, nil
+			})
+		},
+	}
+
+	setCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the changes to shuttle.yaml as a diff without writing them")
+	setCmd.Flags().BoolVar(&asString, "string", false, "Set the value as a string rather than typing it like in yaml")
+
+	return setCmd
+}
+
+func newUnset(uii *ui.UI, contextProvider contextProvider) *cobra.Command {
+	var dryRun bool
+	unsetCmd := &cobra.Command{
+		Use:   "unset [variable]",
+		Short: "Remove a variable from shuttle.yaml",
+		Long: `Remove a variable from the vars of shuttle.yaml, along with the comments right above it.
+
+Variables use the paths of shuttle get, except for wildcards. Removing a variable that isn't set does nothing.`,
+		Example: `  shuttle unset docker.replicas
+  shuttle unset services[-1] --dry-run`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return editShuttleFile(cmd.OutOrStdout(), uii, contextProvider, dryRun, func(content []byte) ([]byte, error) {
+				updated, found, err := config.UnsetVar(content, args[0])
+				if err != nil {
+					return nil, errors.NewExitCode(2, "Could not unset variable: %s", err)
+				}
+				if !found {
+					uii.Infoln("%s is not set", args[0])
+				}
+				return updated, nil
+			})
+		},
+	}
+
+	unsetCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the changes to shuttle.yaml as a diff without writing them")
+
+	return unsetCmd
+}
+
+// editShuttleFile replaces the content of the shuttle.yaml of the project with the result of edit. With dryRun the
+// changes are printed as a diff to w instead
+func editShuttleFile(w io.Writer, uii *ui.UI, contextProvider contextProvider, dryRun bool, edit func([]byte) ([]byte, error)) error {
+	projectContext, err := contextProvider()
+	if err != nil {
+		return err
+	}
+
+	shuttleFile := shuttleFilePath(projectContext)
+	info, err := os.Stat(shuttleFile)
+	if err != nil {
+		return fmt.Errorf("failed to read shuttle.yaml: %w", err)
+	}
+	content, err := os.ReadFile(shuttleFile)
+	if err != nil {
+		return fmt.Errorf("failed to read shuttle.yaml: %w", err)
+	}
+
+	updated, err := edit(content)
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        splitLines(string(content)),
+			B:        splitLines(string(updated)),
+			FromFile: "shuttle.yaml",
+			FromDate: "current",
+			ToFile:   "shuttle.yaml",
+			ToDate:   "updated",
+			Context:  3,
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(w, diff)
+		return nil
+	}
+
+	if string(updated) == string(content) {
+		return nil
+	}
+	if err := os.WriteFile(shuttleFile, updated, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to write shuttle.yaml: %w", err)
+	}
+	uii.Verboseln("Updated %s", shuttleFile)
+	return nil
+}
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetDryRun(t *testing.T) {
+	testCases := []testCase{
+		{
+			name:  "existing variable",
+			input: args("-p", "testdata/project", "set", "nested.sub.field", "qux", "--dry-run"),
+			stdoutput: `--- shuttle.yaml	current
++++ shuttle.yaml	updated
+@@ -5,7 +5,7 @@
+   nested:
+     var: foo
+     sub:
+-      field: baz
++      field: qux
+   services:
+     - name: api
+       ports: [80, 443]
+`,
+			erroutput: "",
+			err:       nil,
+		},
+		{
+			name:  "typed value in list",
+			input: args("-p", "testdata/project", "set", "services[0].ports[-1]", "8443", "--dry-run"),
+			stdoutput: `--- shuttle.yaml	current
++++ shuttle.yaml	updated
+@@ -8,7 +8,7 @@
+       field: baz
+   services:
+     - name: api
+-      ports: [80, 443]
++      ports: [80, 8443]
+     - name: worker
+ scripts:
+   hello_stdout:
+`,
+			erroutput: "",
+			err:       nil,
+		},
+		{
+			name:      "wildcard",
+			input:     args("-p", "testdata/project", "set", "services.*.name", "api", "--dry-run"),
+			stdoutput: "",
+			erroutput: "Error: exit code 2 - Could not set variable: services.*.name: wildcards can't be set\n",
+			err:       errors.New("exit code 2 - Could not set variable: services.*.name: wildcards can't be set"),
+		},
+	}
+	executeTestCases(t, testCases)
+}
+
+func TestUnsetDryRun(t *testing.T) {
+	testCases := []testCase{
+		{
+			name:  "list element",
+			input: args("-p", "testdata/project", "unset", "services[-1]", "--dry-run"),
+			stdoutput: `--- shuttle.yaml	current
++++ shuttle.yaml	updated
+@@ -9,7 +9,6 @@
+   services:
+     - name: api
+       ports: [80, 443]
+-    - name: worker
+ scripts:
+   hello_stdout:
+     actions:
+`,
+			erroutput: "",
+			err:       nil,
+		},
+		{
+			name:      "missing variable",
+			input:     args("-p", "testdata/project", "unset", "nested.missing", "--dry-run"),
+			stdoutput: "",
+			erroutput: "nested.missing is not set\n",
+			err:       nil,
+		},
+	}
+	executeTestCases(t, testCases)
+}
+
+func TestSetUnset(t *testing.T) {
+	projectPath := t.TempDir()
+	shuttleFile := path.Join(projectPath, "shuttle.yaml")
+	require.NoError(t, os.WriteFile(shuttleFile, []byte(`plan: false
+vars:
+  # the image to deploy
+  image: shuttle # from the build
+  replicas: 1
+`), 0o644))
+
+	testCases := []testCase{
+		{
+			name:  "set",
+			input: args("-p", projectPath, "set", "replicas", "3"),
+		},
+		{
+			name:  "set new variable",
+			input: args("-p", projectPath, "set", "k8s.debug", "true"),
+		},
+		{
+			name:  "set version",
+			input: args("-p", projectPath, "set", "tag", "1.20"),
+		},
+		{
+			name:  "set code with leading zero",
+			input: args("-p", projectPath, "set", "code", "010"),
+		},
+		{
+			name:  "set yaml 1.1 boolean",
+			input: args("-p", projectPath, "set", "answer", "no"),
+		},
+		{
+			name:  "set string",
+			input: args("-p", projectPath, "set", "debug", "true", "--string"),
+		},
+		{
+			name:  "unset",
+			input: args("-p", projectPath, "unset", "image"),
+		},
+	}
+	executeTestCases(t, testCases)
+
+	content, err := os.ReadFile(shuttleFile)
+	require.NoError(t, err)
+	assert.Equal(t, `plan: false
+vars:
+  replicas: 3
+  k8s:
+    debug: true
+  tag: "1.20"
+  code: "010"
+  answer: "no"
+  debug: "true"
+`, string(content))
+}
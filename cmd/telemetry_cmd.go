@@ -1,8 +1,10 @@
 package cmd
 
 import (
+	stdcontext "context"
 	"log"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -16,7 +18,11 @@ func newTelemetry(uii *ui.UI) *cobra.Command {
 		Short: "Shuttle telemetry",
 	}
 
-	cmd.AddCommand(newTelemetryUploadCmd(uii))
+	cmd.AddCommand(
+		newTelemetryUploadCmd(uii),
+		newTelemetryStatsCmd(uii),
+		newTelemetryShowCmd(uii),
+	)
 
 	return cmd
 }
@@ -26,6 +32,12 @@ func newTelemetryUploadCmd(uii *ui.UI) *cobra.Command {
 		availabilityUrl string
 		cleanUp         bool
 		uploadUrl       string
+		compress        bool
+		batchSize       int
+		minFileAge      time.Duration
+		maxAttempts     int
+		preview         bool
+		redactionPolicy string
 	)
 
 	cmd := &cobra.Command{
@@ -35,7 +47,7 @@ func newTelemetryUploadCmd(uii *ui.UI) *cobra.Command {
 			uii.SetContext(ui.LevelSilent)
 
 			url := os.Getenv("SHUTTLE_REMOTE_TRACING_URL")
-			if url == "" && uploadUrl == "" {
+			if url == "" && uploadUrl == "" && !preview {
 				log.Fatalln("SHUTTLE_REMOTE_TRACING_URL or upload-url is not set")
 			}
 
@@ -48,7 +60,36 @@ func newTelemetryUploadCmd(uii *ui.UI) *cobra.Command {
 				options = append(options, telemetry.WithAvailabilityCheck(availabilityUrl))
 			}
 
-			options = append(options, telemetry.WithCleanUp(cleanUp))
+			options = append(options,
+				telemetry.WithCleanUp(cleanUp),
+				telemetry.WithCompression(compress),
+				telemetry.WithBatchSize(batchSize),
+				telemetry.WithMinFileAge(minFileAge),
+				telemetry.WithRetry(maxAttempts, 500*time.Millisecond),
+			)
+
+			redaction, err := telemetry.RedactionPolicyFromEnv()
+			if redactionPolicy != "" {
+				redaction, err = telemetry.LoadRedactionPolicy(redactionPolicy)
+			}
+			if err != nil {
+				log.Fatalf("failed to load redaction policy: %s", err)
+			}
+			options = append(options, telemetry.WithRedactionPolicy(redaction))
+
+			if preview {
+				// print the batches instead of sending them, and leave files and locks untouched
+				options = append(options,
+					telemetry.WithNoLock(),
+					telemetry.WithDefaultAvailabilityCheck(),
+					telemetry.WithCleanUp(false),
+					telemetry.WithUploadFunction(
+						func(ctx stdcontext.Context, url string, events []telemetry.UploadTraceEvent) error {
+							return writeJSON(cmd.OutOrStdout(), events)
+						},
+					),
+				)
+			}
 
 			uploader := telemetry.NewTelemetryUploader(url, options...)
 
@@ -64,6 +105,18 @@ func newTelemetryUploadCmd(uii *ui.UI) *cobra.Command {
 		StringVar(&availabilityUrl, "availability-url", "", "availability url is an address that needs to return a 200 http OK before continuing to upload, if anything else is returned, this command exits early")
 	cmd.PersistentFlags().
 		BoolVar(&cleanUp, "clean-up", true, "removes shuttle-telemetry files after upload")
+	cmd.PersistentFlags().
+		BoolVar(&compress, "gzip", true, "gzip compresses the request bodies sent to the upload url")
+	cmd.PersistentFlags().
+		IntVar(&batchSize, "batch-size", 500, "maximum amount of trace events sent in a single request")
+	cmd.PersistentFlags().
+		DurationVar(&minFileAge, "min-file-age", time.Minute, "only upload telemetry files which haven't been modified for this long, as they may still be in use")
+	cmd.PersistentFlags().
+		IntVar(&maxAttempts, "max-attempts", 5, "how many times a batch is attempted if the upload url responds with 429 or 5xx, backing off exponentially")
+	cmd.PersistentFlags().
+		BoolVar(&preview, "preview", false, "print the batches of trace events exactly as they would be sent, without uploading or removing any files")
+	cmd.PersistentFlags().
+		StringVar(&redactionPolicy, "redaction-policy", "", "path to a telemetry redaction policy, defaults to SHUTTLE_TELEMETRY_REDACTION_POLICY")
 
 	return cmd
 }
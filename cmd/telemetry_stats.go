@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/lunarway/shuttle/pkg/telemetry"
+	"github.com/lunarway/shuttle/pkg/ui"
+)
+
+type telemetryReadFlags struct {
+	location string
+	since    time.Duration
+	output   string
+}
+
+func (f *telemetryReadFlags) register(cmd *cobra.Command) {
+	cmd.Flags().
+		StringVar(&f.location, "location", "", "folder containing shuttle-telemetry files, defaults to SHUTTLE_REMOTE_LOG_LOCATION or ~/.local/share/shuttle/telemetry")
+	cmd.Flags().
+		DurationVar(&f.since, "since", 0, "only include events recorded within this duration, e.g. 168h. Includes everything if not set")
+	cmd.Flags().
+		StringVarP(&f.output, "output", "o", "table", "output format: table or json")
+}
+
+func (f *telemetryReadFlags) readEvents(cmd *cobra.Command) ([]telemetry.UploadTraceEvent, error) {
+	if f.output != "table" && f.output != "json" {
+		return nil, fmt.Errorf("unknown output format '%s', must be one of: table, json", f.output)
+	}
+
+	location := f.location
+	if location == "" {
+		location = telemetry.RemoteLogLocation()
+	}
+
+	events, err := telemetry.ReadEvents(cmd.Context(), location)
+	if err != nil {
+		return nil, err
+	}
+	if f.since > 0 {
+		events = telemetry.FilterEventsSince(events, time.Now().Add(-f.since))
+	}
+
+	return events, nil
+}
+
+func newTelemetryStatsCmd(uii *ui.UI) *cobra.Command {
+	var (
+		flags   telemetryReadFlags
+		slowest int
+	)
+
+	cmd := &cobra.Command{
+		Use:          "stats",
+		Short:        "Show statistics over locally recorded shuttle telemetry",
+		Long:         "Aggregates the locally recorded telemetry by command and script, showing run counts, failure rates, p50 and p95 durations and the slowest runs.\nTelemetry is only recorded when SHUTTLE_REMOTE_TRACING is set.",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			uii.SetContext(ui.LevelError)
+
+			events, err := flags.readEvents(cmd)
+			if err != nil {
+				return err
+			}
+
+			stats := telemetry.Aggregate(events, slowest)
+			if flags.output == "json" {
+				return writeJSON(cmd.OutOrStdout(), stats)
+			}
+
+			return writeStatsTable(cmd.OutOrStdout(), stats)
+		},
+	}
+
+	flags.register(cmd)
+	cmd.Flags().IntVar(&slowest, "slowest", 10, "number of slowest runs to show")
+
+	return cmd
+}
+
+func newTelemetryShowCmd(uii *ui.UI) *cobra.Command {
+	var (
+		flags telemetryReadFlags
+		limit int
+	)
+
+	cmd := &cobra.Command{
+		Use:          "show",
+		Short:        "Show the most recent locally recorded shuttle runs",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			uii.SetContext(ui.LevelError)
+
+			events, err := flags.readEvents(cmd)
+			if err != nil {
+				return err
+			}
+
+			runs := telemetry.Runs(events)
+			if limit >= 0 && len(runs) > limit {
+				runs = runs[:limit]
+			}
+			if flags.output == "json" {
+				return writeJSON(cmd.OutOrStdout(), runs)
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			writeRunsTable(w, runs)
+			return w.Flush()
+		},
+	}
+
+	flags.register(cmd)
+	cmd.Flags().IntVar(&limit, "limit", 20, "number of runs to show")
+
+	return cmd
+}
+
+func writeJSON(w io.Writer, value interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+func writeStatsTable(out io.Writer, stats telemetry.Stats) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Runs: %d (%d events)\n\n", stats.Runs, stats.Events)
+
+	fmt.Fprintln(w, "COMMAND\tRUNS\tFAILURES\tFAILURE RATE\tP50\tP95")
+	for _, command := range stats.Commands {
+		fmt.Fprintf(
+			w,
+			"%s\t%d\t%d\t%.1f%%\t%s\t%s\n",
+			command.Command,
+			command.Runs,
+			command.Failures,
+			command.FailureRate*100,
+			formatDuration(command.P50),
+			formatDuration(command.P95),
+		)
+	}
+
+	if len(stats.Slowest) > 0 {
+		fmt.Fprintln(w, "\nSlowest runs:")
+		writeRunsTable(w, stats.Slowest)
+	}
+
+	return w.Flush()
+}
+
+func writeRunsTable(w io.Writer, runs []telemetry.Run) {
+	fmt.Fprintln(w, "STARTED\tCOMMAND\tDURATION\tSTATUS\tRUN ID")
+	for _, run := range runs {
+		status := "ok"
+		switch {
+		case run.Failed:
+			status = "failed"
+		case !run.Completed:
+			status = "incomplete"
+		}
+
+		started := "-"
+		if !run.Start.IsZero() {
+			started = run.Start.Local().Format(time.RFC3339)
+		}
+
+		duration := "-"
+		if run.Completed {
+			duration = formatDuration(run.Duration)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", started, run.Command, duration, status, run.RunID)
+	}
+}
+
+func formatDuration(d time.Duration) string {
+	if d == 0 {
+		return "-"
+	}
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+
+	return d.Round(100 * time.Millisecond).String()
+}
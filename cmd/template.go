@@ -1,16 +1,20 @@
 package cmd
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"os"
 	"path"
+	"path/filepath"
 	"strings"
-	"text/template"
 
-	"github.com/pkg/errors"
+	pkgerrors "github.com/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
 	"github.com/spf13/cobra"
 
+	"github.com/lunarway/shuttle/pkg/config"
+	"github.com/lunarway/shuttle/pkg/errors"
 	tmplFuncs "github.com/lunarway/shuttle/pkg/templates"
 	"github.com/lunarway/shuttle/pkg/ui"
 )
@@ -23,19 +27,42 @@ type context struct {
 }
 
 func newTemplate(uii *ui.UI, contextProvider contextProvider) *cobra.Command {
-	var templateOutput, leftDelimArg, rightDelimArg, delimsArg string
-	var ignoreProjectOverrides bool
+	var templateOutput, templateOutputDir, leftDelimArg, rightDelimArg, delimsArg string
+	var ignoreProjectOverrides, check, write bool
+	var values config.Values
 
 	templateCmd := &cobra.Command{
 		Use:   "template [template]",
 		Short: "Execute a template",
-		Args:  cobra.MinimumNArgs(1),
+		Long: `Execute a template file, or every file in a template directory.
+
+Templates are looked up in the templates directory and root of the project, and then the plan. Directory templates
+are rendered into the output directory, which defaults to the temporary directory. Their file and directory names are
+templates as well, and the .tmpl suffix is stripped. Files rendering empty are skipped. A file in the project
+overrides the file with the same path in the plan.
+
+With --check the template is rendered to memory and compared with the files in --output-dir or --output, printing a
+diff if they are out of date. --write updates them.
+
+Partials are files named _<name>.tmpl in the templates directory of the project and the plan, and are included in
+templates with {{ include "<name>" . }}. A partial in the project overrides the partial of the same name in the plan.
+
+Vars are the vars of shuttle.yaml, with the yaml files of --values and the --set overrides merged over them, so a
+template can render variants per environment.
+
+With --strict missing vars fail the template, instead of rendering "<no value>" or empty values. Plans enable it by
+default with templates.strict in plan.yaml.`,
+		Args: cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			templateName := args[0]
 			ctx := cmd.Context()
 			ctx, _, _, traceEnd := trace(ctx, "template", args)
 			defer traceEnd()
 
+			if check && write {
+				return fmt.Errorf("--check and --write can't be used together")
+			}
+
 			projectContext, err := contextProvider()
 			if err != nil {
 				return err
@@ -43,27 +70,19 @@ func newTemplate(uii *ui.UI, contextProvider contextProvider) *cobra.Command {
 
 			namedArgs := map[string]string{}
 			for _, arg := range args[1:] {
-				parts := strings.SplitN(arg, "=", 2)
-				namedArgs[parts[0]] = parts[1]
-			}
-
-			planPaths := []string{
-				path.Join(projectContext.LocalPlanPath, "templates", templateName),
-				path.Join(projectContext.LocalPlanPath, templateName),
-			}
-
-			projectPaths := []string{
-				path.Join(projectContext.ProjectPath, "templates", templateName),
-				path.Join(projectContext.ProjectPath, templateName),
+				key, value, ok := strings.Cut(arg, "=")
+				if !ok {
+					return errors.NewExitCode(2, "Argument '%s' must be in the form key=value", arg)
+				}
+				namedArgs[key] = value
 			}
 
-			var paths []string
-			if ignoreProjectOverrides {
-				paths = planPaths
-			} else {
-				paths = append(projectPaths, planPaths...)
+			vars, err := values.Apply(projectContext.Config.Variables)
+			if err != nil {
+				return err
 			}
 
+			paths := templatePaths(projectContext, templateName, ignoreProjectOverrides)
 			templatePath := resolveFirstPath(paths)
 			if templatePath == "" {
 				return fmt.Errorf("template `%s` not found", templateName)
@@ -73,37 +92,107 @@ func newTemplate(uii *ui.UI, contextProvider contextProvider) *cobra.Command {
 			if err != nil {
 				return err
 			}
-
-			tmpl, err := template.New(templateName).
-				Delims(leftDelim, rightDelim).
-				Funcs(tmplFuncs.GetFuncMap()).
-				ParseFiles(templatePath)
-			if err != nil {
-				uii.Errorln("Parse template file failed\nFile: %s", templatePath)
-				return err
-			}
+			// failing templates and out of date files aren't usage errors
+			cmd.SilenceUsage = true
 
 			context := context{
 				Args:        namedArgs,
-				Vars:        projectContext.Config.Variables,
+				Vars:        vars,
 				PlanPath:    projectContext.LocalPlanPath,
 				ProjectPath: projectContext.ProjectPath,
 			}
+			renderer := &tmplFuncs.Renderer{
+				LeftDelim:  leftDelim,
+				RightDelim: rightDelim,
+				Strict:     strictTemplates(cmd, projectContext),
+				Partials:   partialDirs(projectContext, ignoreProjectOverrides),
+				Data:       context,
+				Vars:       vars,
+			}
+
+			if check || write {
+				var target string
+				switch {
+				case templateOutputDir != "":
+					target = resolveOutputDir(projectContext.ProjectPath, templateOutputDir)
+				case templateOutput != "":
+					target = path.Join(projectContext.TempDirectoryPath, templateOutput)
+				default:
+					return fmt.Errorf("--check and --write require --output-dir or --output to compare with")
+				}
+				if !dirAvailable(templatePath) && templateOutputDir != "" {
+					target = path.Join(target, strings.TrimSuffix(path.Base(templatePath), tmplFuncs.Suffix))
+				}
+
+				outdated, err := syncTemplate(cmd.OutOrStdout(), uii, projectContext.ProjectPath, renderer, paths, templatePath, target, write)
+				if err != nil {
+					return err
+				}
+				if outdated > 0 && check {
+					return errors.NewExitCode(1, "%d generated files are out of date, update them with --write", outdated)
+				}
+				return nil
+			}
+
+			if dirAvailable(templatePath) {
+				outputDir := path.Join(projectContext.TempDirectoryPath, path.Base(templatePath))
+				switch {
+				case templateOutputDir != "":
+					outputDir = resolveOutputDir(projectContext.ProjectPath, templateOutputDir)
+				case templateOutput != "":
+					outputDir = path.Join(projectContext.TempDirectoryPath, templateOutput)
+				}
+
+				files, err := renderTemplateFiles(renderer, paths, templatePath)
+				if err != nil {
+					uii.Errorln(
+						"Failed to execute template directory\nPlan: %s\nProject: %s",
+						context.PlanPath,
+						context.ProjectPath,
+					)
+					return err
+				}
+				for _, file := range files {
+					uii.Verboseln("Rendered %s from %s", path.Join(outputDir, file.Path), file.Source)
+				}
+				return tmplFuncs.WriteFiles(outputDir, files)
+			}
+
+			tmpl, err := renderer.ParseFile(templatePath)
+			if err != nil {
+				uii.Errorln("Parse template file failed\nFile: %s", templatePath)
+				return err
+			}
+
 			var output io.Writer
-			if templateOutput == "" {
-				output = cmd.OutOrStdout()
-			} else {
+			switch {
+			case templateOutputDir != "":
+				outputDir := resolveOutputDir(projectContext.ProjectPath, templateOutputDir)
+				if err := os.MkdirAll(outputDir, os.ModePerm); err != nil {
+					return err
+				}
+				templateOutputPath := path.Join(outputDir, strings.TrimSuffix(path.Base(templatePath), tmplFuncs.Suffix))
+				file, err := os.Create(templateOutputPath)
+				if err != nil {
+					return pkgerrors.WithMessagef(err, "create template output file '%s'", templateOutputPath)
+				}
+				defer file.Close()
+				output = file
+			case templateOutput != "":
 				// TODO: This is probably not the right place to initialize the temp dir?
 				os.MkdirAll(projectContext.TempDirectoryPath, os.ModePerm)
 				templateOutputPath := path.Join(projectContext.TempDirectoryPath, templateOutput)
 				file, err := os.Create(templateOutputPath)
 				if err != nil {
-					return errors.WithMessagef(err, "create template output file '%s'", templateOutputPath)
+					return pkgerrors.WithMessagef(err, "create template output file '%s'", templateOutputPath)
 				}
+				defer file.Close()
 				output = file
+			default:
+				output = cmd.OutOrStdout()
 			}
 
-			err = tmpl.ExecuteTemplate(output, path.Base(templatePath), context)
+			err = tmpl.Execute(output, context)
 			if err != nil {
 				uii.Errorln(
 					"Failed to execute template\nPlan: %s\nProject: %s",
@@ -118,6 +207,8 @@ func newTemplate(uii *ui.UI, contextProvider contextProvider) *cobra.Command {
 
 	templateCmd.Flags().
 		StringVarP(&templateOutput, "output", "o", "", "Select filename to output file to in temporary directory")
+	templateCmd.Flags().
+		StringVarP(&templateOutputDir, "output-dir", "", "", "Select directory to render the template into, relative to the project")
 	templateCmd.Flags().
 		StringVarP(&delimsArg, "delims", "", "", "Select delims for templating. Split by ','. If ',' is in the delims, then use --left-delim and --right-delim instead")
 	templateCmd.Flags().
@@ -126,10 +217,243 @@ func newTemplate(uii *ui.UI, contextProvider contextProvider) *cobra.Command {
 		StringVarP(&rightDelimArg, "right-delim", "", "", "Select delims for templating. Defaults to '}}'")
 	templateCmd.Flags().
 		BoolVarP(&ignoreProjectOverrides, "ignore-project-overrides", "", false, "Set flag to ignore template files located in the project folder")
+	templateCmd.Flags().
+		BoolVarP(&check, "check", "", false, "Compare the rendered template with the files in the output and print a diff if they are out of date")
+	templateCmd.Flags().
+		BoolVarP(&write, "write", "", false, "Update the files in the output if they are out of date")
+
+	addValuesFlags(templateCmd.Flags(), &values)
+	templateCmd.PersistentFlags().
+		Bool("strict", false, "Fail on missing vars instead of rendering empty values. Defaults to templates.strict of the plan")
+
+	templateCmd.AddCommand(newTemplateCheckAll(uii, contextProvider))
 
 	return templateCmd
 }
 
+func newTemplateCheckAll(uii *ui.UI, contextProvider contextProvider) *cobra.Command {
+	var write bool
+
+	cmd := &cobra.Command{
+		Use:   "check-all",
+		Short: "Check that the files generated by the templates listed in shuttle.yaml are up to date",
+		Long: `Check that the files generated by the templates listed in shuttle.yaml are up to date.
+
+Generated files are listed in the templates section of shuttle.yaml. output is relative to the project, and is a
+file for file templates and a directory for directory templates:
+
+  templates:
+    - template: Dockerfile.tmpl
+      output: Dockerfile
+      args:
+        GO_VERSION: "1.22"
+    - template: manifests
+      output: k8s
+
+A diff is printed for every file out of date, and the command fails. --write updates the files instead.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			projectContext, err := contextProvider()
+			if err != nil {
+				return err
+			}
+
+			if len(projectContext.Config.Templates) == 0 {
+				uii.Infoln("No templates listed in shuttle.yaml")
+				return nil
+			}
+
+			outdated := 0
+			for _, generated := range projectContext.Config.Templates {
+				if generated.Template == "" || generated.Output == "" {
+					return fmt.Errorf("templates in shuttle.yaml require both template and output")
+				}
+
+				paths := templatePaths(projectContext, generated.Template, false)
+				templatePath := resolveFirstPath(paths)
+				if templatePath == "" {
+					return fmt.Errorf("template `%s` not found", generated.Template)
+				}
+
+				leftDelim, rightDelim, err := parseDelims("", "", generated.Delims)
+				if err != nil {
+					return fmt.Errorf("template `%s`: %w", generated.Template, err)
+				}
+
+				renderer := &tmplFuncs.Renderer{
+					LeftDelim:  leftDelim,
+					RightDelim: rightDelim,
+					Strict:     strictTemplates(cmd, projectContext),
+					Partials:   partialDirs(projectContext, false),
+					Data: context{
+						Args:        generated.Args,
+						Vars:        projectContext.Config.Variables,
+						PlanPath:    projectContext.LocalPlanPath,
+						ProjectPath: projectContext.ProjectPath,
+					},
+					Vars: projectContext.Config.Variables,
+				}
+
+				target := resolveOutputDir(projectContext.ProjectPath, generated.Output)
+				n, err := syncTemplate(cmd.OutOrStdout(), uii, projectContext.ProjectPath, renderer, paths, templatePath, target, write)
+				if err != nil {
+					return fmt.Errorf("template `%s`: %w", generated.Template, err)
+				}
+				outdated += n
+			}
+
+			if outdated > 0 && !write {
+				return errors.NewExitCode(
+					1,
+					"%d generated files are out of date, update them with shuttle template check-all --write",
+					outdated,
+				)
+			}
+			if outdated == 0 {
+				uii.Infoln("Generated files are up to date")
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&write, "write", false, "Update the files that are out of date instead of failing")
+
+	return cmd
+}
+
+// templatePaths returns the paths a template is looked up in, in order of precedence
+func templatePaths(projectContext config.ShuttleProjectContext, templateName string, ignoreProjectOverrides bool) []string {
+	planPaths := []string{
+		path.Join(projectContext.LocalPlanPath, "templates", templateName),
+		path.Join(projectContext.LocalPlanPath, templateName),
+	}
+
+	if ignoreProjectOverrides {
+		return planPaths
+	}
+
+	projectPaths := []string{
+		path.Join(projectContext.ProjectPath, "templates", templateName),
+		path.Join(projectContext.ProjectPath, templateName),
+	}
+	return append(projectPaths, planPaths...)
+}
+
+// partialDirs returns the directories of the partials available to templates, ordered like templatePaths
+func partialDirs(projectContext config.ShuttleProjectContext, ignoreProjectOverrides bool) []string {
+	planDir := path.Join(projectContext.LocalPlanPath, "templates")
+	if ignoreProjectOverrides {
+		return []string{planDir}
+	}
+
+	return []string{path.Join(projectContext.ProjectPath, "templates"), planDir}
+}
+
+// renderTemplateFiles renders the template in templatePath to memory. Directory templates are rendered from every
+// directory in paths, see tmplFuncs.Renderer.RenderDir, while a file template is rendered as a single file
+func renderTemplateFiles(renderer *tmplFuncs.Renderer, paths []string, templatePath string) ([]tmplFuncs.File, error) {
+	if dirAvailable(templatePath) {
+		// every directory found is a source, so the files of the plan are rendered unless overridden
+		sources := make([]string, 0, len(paths))
+		for _, p := range paths {
+			if dirAvailable(p) {
+				sources = append(sources, p)
+			}
+		}
+		return renderer.RenderDir(sources...)
+	}
+
+	info, err := os.Stat(templatePath)
+	if err != nil {
+		return nil, err
+	}
+	var content bytes.Buffer
+	if err := renderer.RenderFile(&content, templatePath); err != nil {
+		return nil, err
+	}
+
+	return []tmplFuncs.File{{
+		Path:    strings.TrimSuffix(path.Base(templatePath), tmplFuncs.Suffix),
+		Source:  templatePath,
+		Content: content.Bytes(),
+		Mode:    info.Mode().Perm(),
+	}}, nil
+}
+
+// syncTemplate renders the template in templatePath and compares it with target, which is a directory for directory
+// templates and a file for file templates. A diff is printed to w for every file out of date, or the file is updated
+// if write is set. It returns the number of files out of date.
+//
+// Files in a target directory which aren't rendered by the template are left alone
+func syncTemplate(
+	w io.Writer,
+	uii *ui.UI,
+	projectPath string,
+	renderer *tmplFuncs.Renderer,
+	paths []string,
+	templatePath string,
+	target string,
+	write bool,
+) (int, error) {
+	files, err := renderTemplateFiles(renderer, paths, templatePath)
+	if err != nil {
+		return 0, err
+	}
+
+	targetDir := target
+	if !dirAvailable(templatePath) {
+		targetDir = path.Dir(target)
+		files[0].Path = path.Base(target)
+	}
+
+	outdated := make([]tmplFuncs.File, 0)
+	for _, file := range files {
+		filePath := path.Join(targetDir, file.Path)
+		current, err := os.ReadFile(filePath)
+		if err != nil && !os.IsNotExist(err) {
+			return 0, err
+		}
+		if err == nil && bytes.Equal(current, file.Content) {
+			continue
+		}
+		outdated = append(outdated, file)
+
+		displayPath := filePath
+		if relative, err := filepath.Rel(projectPath, filePath); err == nil && !strings.HasPrefix(relative, "..") {
+			displayPath = relative
+		}
+
+		if write {
+			uii.Infoln("Updated %s", displayPath)
+			continue
+		}
+
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        splitLines(string(current)),
+			B:        splitLines(string(file.Content)),
+			FromFile: displayPath,
+			FromDate: "current",
+			ToFile:   displayPath,
+			ToDate:   "rendered",
+			Context:  3,
+		})
+		if err != nil {
+			return 0, err
+		}
+		fmt.Fprint(w, diff)
+	}
+
+	if write {
+		if err := tmplFuncs.WriteFiles(targetDir, outdated); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(outdated), nil
+}
+
 func resolveFirstPath(paths []string) string {
 	for _, templatePath := range paths {
 		if fileAvailable(templatePath) {
@@ -139,6 +463,37 @@ func resolveFirstPath(paths []string) string {
 	return ""
 }
 
+// strictTemplates returns whether --strict is set, falling back to the default of the plan
+func strictTemplates(cmd *cobra.Command, projectContext config.ShuttleProjectContext) bool {
+	if cmd.Flags().Changed("strict") {
+		strict, _ := cmd.Flags().GetBool("strict")
+		return strict
+	}
+	return projectContext.Plan.Templates.Strict
+}
+
+// splitLines splits s into lines keeping their line endings, which is the input expected by difflib
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func dirAvailable(name string) bool {
+	info, err := os.Stat(name)
+	return err == nil && info.IsDir()
+}
+
+// resolveOutputDir resolves relative output directories from the project
+func resolveOutputDir(projectPath, outputDir string) string {
+	if path.IsAbs(outputDir) {
+		return outputDir
+	}
+	return path.Join(projectPath, outputDir)
+}
+
 func fileAvailable(name string) bool {
 	if _, err := os.Stat(name); err != nil {
 		if os.IsNotExist(err) {
@@ -1,7 +1,15 @@
 package cmd
 
 import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestTemplate(t *testing.T) {
@@ -33,6 +41,26 @@ FROM golang:1.17-alpine
 			),
 			stdoutput: `FROM golang:1.17-alpine
 LABEL svc=shuttle
+`,
+			erroutput: "",
+			err:       nil,
+		},
+		{
+			name: "values and set",
+			input: args(
+				"-p",
+				"testdata/project",
+				"template",
+				"../values-template.tmpl",
+				"-f",
+				"testdata/project/values/prod.yaml",
+				"--set",
+				"replicas=3",
+			),
+			stdoutput: `service: api
+var: bar
+field: baz
+replicas: 3
 `,
 			erroutput: "",
 			err:       nil,
@@ -40,3 +68,216 @@ LABEL svc=shuttle
 	}
 	executeTestCases(t, testCases)
 }
+
+func TestTemplateInvalidArgument(t *testing.T) {
+	testCases := []testCase{
+		{
+			name: "argument without value",
+			input: args(
+				"-p",
+				"testdata/project",
+				"template",
+				"../custom-template.tmpl",
+				"GO_VERSION",
+			),
+			err: errors.New("exit code 2 - Argument 'GO_VERSION' must be in the form key=value"),
+		},
+	}
+	executeTestCasesWithCustomAssertion(t, testCases, func(t *testing.T, tc testCase, stdout, stderr string) {
+		assert.Contains(t, stdout, "Usage:", "usage not printed")
+	})
+}
+
+func TestTemplateStrict(t *testing.T) {
+	testCases := []testCase{
+		{
+			name: "plan default",
+			input: args(
+				"--project",
+				"./testdata/project-local/service",
+				"--plan",
+				"./testdata/project-local/strict-plan",
+				"template",
+				"image.yaml.tmpl",
+			),
+			stdoutput: "language: go\nimage: ",
+			err: errors.New(
+				`template: image.yaml.tmpl:2:10: executing "image.yaml.tmpl" at <string "image" .Vars>: error calling string: var image is missing`,
+			),
+		},
+		{
+			name: "disabled with flag",
+			input: args(
+				"--project",
+				"./testdata/project-local/service",
+				"--plan",
+				"./testdata/project-local/strict-plan",
+				"template",
+				"image.yaml.tmpl",
+				"--strict=false",
+			),
+			stdoutput: "language: go\nimage: \n",
+			err:       nil,
+		},
+	}
+	executeTestCasesWithCustomAssertion(t, testCases, func(t *testing.T, tc testCase, stdout, stderr string) {
+		assert.Equal(t, tc.stdoutput, stdout, "std output not as expected")
+	})
+}
+
+func TestTemplatePartials(t *testing.T) {
+	testCases := []testCase{
+		{
+			name: "project overrides plan",
+			input: args(
+				"--project",
+				"./testdata/project-local/service",
+				"--plan",
+				"./testdata/project-local/plan",
+				"template",
+				"metadata.yaml.tmpl",
+			),
+			stdoutput: `metadata:
+  labels:
+    language: go
+    owner: plan
+    team: project
+`,
+			err: nil,
+		},
+		{
+			name: "ignore project overrides",
+			input: args(
+				"--project",
+				"./testdata/project-local/service",
+				"--plan",
+				"./testdata/project-local/plan",
+				"template",
+				"metadata.yaml.tmpl",
+				"--ignore-project-overrides",
+			),
+			stdoutput: `metadata:
+  labels:
+    language: go
+    owner: plan
+    team: plan
+`,
+			err: nil,
+		},
+	}
+	executeTestCasesWithCustomAssertion(t, testCases, func(t *testing.T, tc testCase, stdout, stderr string) {
+		assert.Equal(t, tc.stdoutput, stdout, "std output not as expected")
+	})
+}
+
+func TestTemplateDirectory(t *testing.T) {
+	outputDir := "testdata/project-local/service/.shuttle/temp/manifests"
+	testCases := []testCase{
+		{
+			name: "plan and project files",
+			input: args(
+				"--project",
+				"./testdata/project-local/service",
+				"--plan",
+				"./testdata/project-local/plan",
+				"template",
+				"manifests",
+			),
+			stdoutput: "",
+			err:       nil,
+		},
+	}
+	executeTestCasesWithCustomAssertion(t, testCases, func(t *testing.T, tc testCase, stdout, stderr string) {
+		assert.Equal(t, tc.stdoutput, stdout, "std output not as expected")
+
+		rendered := make(map[string]string)
+		err := filepath.WalkDir(outputDir, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil || entry.IsDir() {
+				return err
+			}
+			content, err := os.ReadFile(file)
+			if err != nil {
+				return err
+			}
+			relative, err := filepath.Rel(outputDir, file)
+			if err != nil {
+				return err
+			}
+			rendered[filepath.ToSlash(relative)] = string(content)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"deployment.yaml": "language: go\n",
+			"go/build.yaml":   "image: golang\n",
+			"service.yaml":    "from: project\n",
+		}, rendered)
+	})
+}
+
+func TestTemplateCheck(t *testing.T) {
+	projectArgs := []string{
+		"--project",
+		"./testdata/project-local/service",
+		"--plan",
+		"./testdata/project-local/plan",
+	}
+	testCases := []testCase{
+		{
+			name:      "up to date",
+			input:     append(args(projectArgs...), "template", "manifests", "--check", "--output-dir", "k8s"),
+			stdoutput: "",
+			err:       nil,
+		},
+		{
+			name:  "out of date",
+			input: append(args(projectArgs...), "template", "manifests", "--check", "--output-dir", "k8s-outdated"),
+			stdoutput: `--- k8s-outdated/deployment.yaml	current
++++ k8s-outdated/deployment.yaml	rendered
+@@ -1 +1 @@
+-language: java
++language: go
+`,
+			err: errors.New("exit code 1 - 1 generated files are out of date, update them with --write"),
+		},
+		{
+			name:      "check-all",
+			input:     append(args(projectArgs...), "template", "check-all"),
+			stdoutput: "",
+			err:       nil,
+		},
+	}
+	executeTestCasesWithCustomAssertion(t, testCases, func(t *testing.T, tc testCase, stdout, stderr string) {
+		assert.Equal(t, tc.stdoutput, stdout, "std output not as expected")
+	})
+}
+
+func TestTemplateWrite(t *testing.T) {
+	outputDir := t.TempDir()
+	require.NoError(t, os.WriteFile(path.Join(outputDir, "service.yaml"), []byte("from: plan\n"), 0o644))
+
+	testCases := []testCase{
+		{
+			name: "updates out of date files",
+			input: args(
+				"--project",
+				"./testdata/project-local/service",
+				"--plan",
+				"./testdata/project-local/plan",
+				"template",
+				"manifests",
+				"--write",
+				"--output-dir",
+				outputDir,
+			),
+			err: nil,
+		},
+	}
+	executeTestCasesWithCustomAssertion(t, testCases, func(t *testing.T, tc testCase, stdout, stderr string) {
+		assert.Contains(t, stderr, "Updated "+path.Join(outputDir, "service.yaml"))
+		content, err := os.ReadFile(path.Join(outputDir, "service.yaml"))
+		require.NoError(t, err)
+		assert.Equal(t, "from: project\n", string(content))
+		assert.FileExists(t, path.Join(outputDir, "go/build.yaml"))
+	})
+}
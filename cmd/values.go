@@ -0,0 +1,14 @@
+package cmd
+
+import (
+	"github.com/spf13/pflag"
+
+	"github.com/lunarway/shuttle/pkg/config"
+)
+
+// addValuesFlags adds the -f, --set and --set-string flags merging extra values over the vars of shuttle.yaml
+func addValuesFlags(flags *pflag.FlagSet, values *config.Values) {
+	flags.StringArrayVarP(&values.Files, "values", "f", nil, "Merge a yaml file over the vars. Can be repeated, later files take precedence")
+	flags.StringArrayVar(&values.Set, "set", nil, "Set a var, i.e. --set docker.replicas=3. Can be repeated, and takes precedence over --values")
+	flags.StringArrayVar(&values.SetString, "set-string", nil, "Set a var to a string, i.e. --set-string docker.tag=1.20. Can be repeated, and takes precedence over --set")
+}
@@ -0,0 +1,458 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"github.com/lunarway/shuttle/pkg/templates"
+)
+
+// SetVar returns the shuttle.yaml content with the var at path set to value, see templates.ParsePath. Missing maps on
+// the path are created.
+//
+// Only the lines of the changed var are rewritten, so comments, ordering and formatting of the rest of the file are
+// preserved. The changed var keeps its comments as well
+func SetVar(content []byte, path string, value interface{}) ([]byte, error) {
+	var valueNode yamlv3.Node
+	if err := valueNode.Encode(value); err != nil {
+		return nil, fmt.Errorf("failed to encode value: %w", err)
+	}
+
+	e, err := newVarsEditor(content, path)
+	if err != nil {
+		return nil, err
+	}
+
+	// shuttle.yaml has no vars
+	if e.vars < 0 {
+		if e.root == nil {
+			e.root = &yamlv3.Node{Kind: yamlv3.MappingNode}
+		}
+		entry := &yamlv3.Node{Kind: yamlv3.MappingNode}
+		entry.Content = []*yamlv3.Node{scalarNode("vars"), nestedNode(e.elements, &valueNode)}
+		return e.insert(e.endLine(e.root)+1, "", entry)
+	}
+
+	walked, err := e.walk()
+	if err != nil {
+		return nil, err
+	}
+	target := blockEntry(walked.entries)
+
+	if walked.missing == len(e.elements) {
+		walked.replace(valueNode)
+		return e.rewrite(target)
+	}
+
+	remaining := e.elements[walked.missing:]
+	for _, element := range remaining {
+		if element.IsIndex && element.Key == "" {
+			return nil, fmt.Errorf("%s: can't create list element [%d]", path, element.Index)
+		}
+	}
+	key := scalarNode(remaining[0].Key)
+	nested := nestedNode(remaining[1:], &valueNode)
+
+	if walked.node.Kind != yamlv3.MappingNode {
+		// replace scalars and nulls on the path with maps
+		walked.replace(yamlv3.Node{Kind: yamlv3.MappingNode})
+	}
+	mapping := walked.node
+	if len(mapping.Content) > 0 && mapping.Style&yamlv3.FlowStyle == 0 {
+		entry := &yamlv3.Node{Kind: yamlv3.MappingNode, Content: []*yamlv3.Node{key, nested}}
+		first := mapping.Content[0]
+		indent := strings.Repeat(" ", first.Column-1)
+		return e.insert(e.endLine(mapping)+1, indent, entry)
+	}
+
+	mapping.Content = append(mapping.Content, key, nested)
+	return e.rewrite(target)
+}
+
+// UnsetVar returns the shuttle.yaml content without the var at path, see SetVar, and whether the var was set
+func UnsetVar(content []byte, path string) ([]byte, bool, error) {
+	e, err := newVarsEditor(content, path)
+	if err != nil {
+		return nil, false, err
+	}
+	if e.vars < 0 {
+		return content, false, nil
+	}
+
+	walked, err := e.walk()
+	if err != nil {
+		return nil, false, err
+	}
+	if walked.missing < len(e.elements) {
+		return content, false, nil
+	}
+
+	var updated []byte
+	if walked.parent.Kind == yamlv3.SequenceNode {
+		walked.parent.Content = append(walked.parent.Content[:walked.index], walked.parent.Content[walked.index+1:]...)
+		updated, err = e.rewrite(blockEntry(walked.entries))
+	} else {
+		updated, err = e.remove(walked.entries)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return updated, true, nil
+}
+
+// varsEditor edits the vars of shuttle.yaml by changing its nodes, and replacing the lines of the changed entries with
+// the encoded nodes
+type varsEditor struct {
+	lines    []string
+	root     *yamlv3.Node
+	elements []templates.PathElement
+	// vars is the index of the vars key in root, or -1 if shuttle.yaml has no vars
+	vars   int
+	indent int
+}
+
+// mapEntry is the key at index of a mapping
+type mapEntry struct {
+	mapping *yamlv3.Node
+	index   int
+	// inFlow is set if the mapping is a flow mapping, or is inside a flow collection
+	inFlow bool
+	// end is the last line of the entry before any changes
+	end int
+}
+
+func (e mapEntry) key() *yamlv3.Node {
+	return e.mapping.Content[e.index]
+}
+
+func (e mapEntry) value() *yamlv3.Node {
+	return e.mapping.Content[e.index+1]
+}
+
+// walked is the result of walking the path of a varsEditor
+type walked struct {
+	// entries are the map entries on the path, starting with vars
+	entries []mapEntry
+	// node is the node at the path, or the last node found on it
+	node *yamlv3.Node
+	// parent of node, and the index of node in it
+	parent *yamlv3.Node
+	index  int
+	// missing is the index of the first element of the path not found, or the length of the path if it is found
+	missing int
+}
+
+// replace replaces the node at the path with value, keeping its line comment
+func (w walked) replace(value yamlv3.Node) {
+	comment := w.node.LineComment
+	*w.node = value
+	if value.Kind == yamlv3.ScalarNode || w.parent.Kind != yamlv3.MappingNode {
+		w.node.LineComment = comment
+		return
+	}
+	// comments of maps and lists are after their key
+	if key := w.parent.Content[w.index-1]; key.LineComment == "" {
+		key.LineComment = comment
+	}
+}
+
+func newVarsEditor(content []byte, path string) (*varsEditor, error) {
+	elements, err := templates.ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, element := range elements {
+		if element.Wildcard {
+			return nil, fmt.Errorf("%s: wildcards can't be set", path)
+		}
+	}
+
+	var document yamlv3.Node
+	if err := yamlv3.Unmarshal(content, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse shuttle.yaml: %w", err)
+	}
+
+	e := &varsEditor{
+		lines:    strings.Split(string(content), "\n"),
+		elements: elements,
+		vars:     -1,
+		indent:   2,
+	}
+	if len(document.Content) == 0 {
+		return e, nil
+	}
+	e.root = document.Content[0]
+	if e.root.Kind != yamlv3.MappingNode {
+		return nil, fmt.Errorf("shuttle.yaml must be a map")
+	}
+
+	e.vars = keyIndex(e.root, "vars")
+	if e.vars >= 0 {
+		vars := e.root.Content[e.vars+1]
+		if vars.Kind == yamlv3.MappingNode && len(vars.Content) > 0 && vars.Style&yamlv3.FlowStyle == 0 {
+			if indent := vars.Content[0].Column - e.root.Content[e.vars].Column; indent >= 2 {
+				e.indent = indent
+			}
+		}
+	}
+
+	return e, nil
+}
+
+// walk follows the path from vars, as far as it exists
+func (e *varsEditor) walk() (walked, error) {
+	inFlow := e.root.Style&yamlv3.FlowStyle != 0
+	w := walked{
+		entries: []mapEntry{e.entry(e.root, e.vars, inFlow)},
+		node:    e.root.Content[e.vars+1],
+		parent:  e.root,
+		index:   e.vars + 1,
+	}
+
+	for i, element := range e.elements {
+		w.missing = i
+		inFlow = inFlow || w.node.Style&yamlv3.FlowStyle != 0
+		switch w.node.Kind {
+		case yamlv3.MappingNode:
+			if element.IsIndex && element.Key == "" {
+				return w, fmt.Errorf("%s: [%d] is an index of a map", pathString(e.elements[:i+1]), element.Index)
+			}
+			index := keyIndex(w.node, element.Key)
+			if index < 0 {
+				return w, nil
+			}
+			w.entries = append(w.entries, e.entry(w.node, index, inFlow))
+			w.parent, w.index, w.node = w.node, index+1, w.node.Content[index+1]
+		case yamlv3.SequenceNode:
+			if !element.IsIndex {
+				return w, fmt.Errorf("%s: %s is a list", pathString(e.elements[:i+1]), pathString(e.elements[:i]))
+			}
+			index := element.Index
+			if index < 0 {
+				index += len(w.node.Content)
+			}
+			if index < 0 || index >= len(w.node.Content) {
+				return w, fmt.Errorf("%s: index out of range of %d elements", pathString(e.elements[:i+1]), len(w.node.Content))
+			}
+			w.parent, w.index, w.node = w.node, index, w.node.Content[index]
+		default:
+			return w, nil
+		}
+	}
+
+	w.missing = len(e.elements)
+	return w, nil
+}
+
+func (e *varsEditor) entry(mapping *yamlv3.Node, index int, inFlow bool) mapEntry {
+	end := e.endLine(mapping.Content[index+1])
+	if line := mapping.Content[index].Line; end < line {
+		end = line
+	}
+	return mapEntry{mapping: mapping, index: index, inFlow: inFlow, end: end}
+}
+
+// rewrite replaces the lines of entry with the encoded entry
+func (e *varsEditor) rewrite(entry mapEntry) ([]byte, error) {
+	key := entry.key()
+	prefix := e.lines[key.Line-1][:key.Column-1]
+
+	encoded, err := e.encode(key, entry.value(), prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.splice(key.Line, entry.end, encoded), nil
+}
+
+// remove removes the last of entries, or rewrites the entry before it if that is simpler
+func (e *varsEditor) remove(entries []mapEntry) ([]byte, error) {
+	entry := entries[len(entries)-1]
+	key := entry.key()
+	line := e.lines[key.Line-1]
+	ownLine := strings.TrimSpace(line[:key.Column-1]) == ""
+
+	entry.mapping.Content = append(entry.mapping.Content[:entry.index], entry.mapping.Content[entry.index+2:]...)
+
+	// vars itself is never removed, so there is always an enclosing entry. Empty maps are rewritten as {}
+	if !ownLine || entry.inFlow || len(entry.mapping.Content) == 0 {
+		return e.rewrite(blockEntry(entries[:len(entries)-1]))
+	}
+
+	// remove the comment lines right above the key as well
+	start := key.Line
+	indent := line[:key.Column-1]
+	for start > 1 {
+		previous := e.lines[start-2]
+		if !strings.HasPrefix(previous, indent+"#") {
+			break
+		}
+		start--
+	}
+
+	return e.splice(start, entry.end, nil), nil
+}
+
+// insert inserts the encoded entry before the line, indented by prefix
+func (e *varsEditor) insert(line int, prefix string, entry *yamlv3.Node) ([]byte, error) {
+	encoded, err := e.encode(entry.Content[0], entry.Content[1], prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.splice(line, line-1, encoded), nil
+}
+
+// encode encodes the entry of key and value, with the first line prefixed by prefix and the rest indented to match
+func (e *varsEditor) encode(key, value *yamlv3.Node, prefix string) ([]string, error) {
+	// the comments above the key, and after the value, are kept in the file as is
+	keyCopy := *key
+	keyCopy.HeadComment = ""
+	clearFootComments(&keyCopy)
+	clearFootComments(value)
+
+	var buf bytes.Buffer
+	encoder := yamlv3.NewEncoder(&buf)
+	encoder.SetIndent(e.indent)
+	entry := &yamlv3.Node{Kind: yamlv3.MappingNode, Content: []*yamlv3.Node{&keyCopy, value}}
+	if err := encoder.Encode(entry); err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", key.Value, err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", key.Value, err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	indent := strings.Repeat(" ", len(prefix))
+	for i, line := range lines {
+		switch {
+		case i == 0:
+			lines[i] = prefix + line
+		case line != "":
+			lines[i] = indent + line
+		}
+	}
+	return lines, nil
+}
+
+// splice replaces the lines from start to end, both 1-based and inclusive, with replacement
+func (e *varsEditor) splice(start, end int, replacement []string) []byte {
+	// content without a trailing newline has its last line at len(lines)
+	if start > len(e.lines) {
+		start = len(e.lines)
+		end = start - 1
+		if e.lines[len(e.lines)-1] != "" {
+			replacement = append([]string{e.lines[len(e.lines)-1]}, replacement...)
+			end = start
+		}
+	}
+
+	lines := make([]string, 0, len(e.lines)+len(replacement))
+	lines = append(lines, e.lines[:start-1]...)
+	lines = append(lines, replacement...)
+	lines = append(lines, e.lines[end:]...)
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// endLine returns the last line of node in the file, or 0 if it isn't from the file
+func (e *varsEditor) endLine(node *yamlv3.Node) int {
+	end := node.Line
+	if node.Kind == yamlv3.ScalarNode && node.Style&(yamlv3.LiteralStyle|yamlv3.FoldedStyle) != 0 {
+		end = e.blockScalarEnd(node)
+	}
+	for _, child := range node.Content {
+		if childEnd := e.endLine(child); childEnd > end {
+			end = childEnd
+		}
+	}
+	// skip the lines of a multi line flow collection
+	if node.Style&yamlv3.FlowStyle != 0 && node.Kind != yamlv3.ScalarNode {
+		for end < len(e.lines) && !strings.ContainsAny(e.lines[end-1], "]}") {
+			end++
+		}
+	}
+	return end
+}
+
+// blockScalarEnd returns the last line of a literal or folded scalar, whose lines don't match the lines of its value
+// when folded. The content ends before the first line indented less than its first line, and trailing blank lines
+// only belong to it if they are kept in the value, as with |+
+func (e *varsEditor) blockScalarEnd(node *yamlv3.Node) int {
+	end := node.Line
+	indent := -1
+	for line := node.Line + 1; line <= len(e.lines); line++ {
+		text := e.lines[line-1]
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		lineIndent := len(text) - len(strings.TrimLeft(text, " "))
+		if indent < 0 {
+			indent = lineIndent
+		}
+		if lineIndent < indent {
+			break
+		}
+		end = line
+	}
+
+	kept := len(node.Value) - len(strings.TrimRight(node.Value, "\n")) - 1
+	if kept > 0 {
+		end = min(end+kept, len(e.lines))
+	}
+	return end
+}
+
+// clearFootComments clears the comments after node, which are kept in the file as is
+func clearFootComments(node *yamlv3.Node) {
+	node.FootComment = ""
+	if len(node.Content) > 0 {
+		clearFootComments(node.Content[len(node.Content)-1])
+	}
+}
+
+// blockEntry returns the last of entries that isn't in a flow collection, as entries of flow collections aren't on
+// lines of their own
+func blockEntry(entries []mapEntry) mapEntry {
+	for i := len(entries) - 1; i > 0; i-- {
+		if !entries[i].inFlow {
+			return entries[i]
+		}
+	}
+	return entries[0]
+}
+
+func keyIndex(mapping *yamlv3.Node, key string) int {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func scalarNode(value string) *yamlv3.Node {
+	return &yamlv3.Node{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: value}
+}
+
+// nestedNode returns value nested in maps by the keys of elements
+func nestedNode(elements []templates.PathElement, value *yamlv3.Node) *yamlv3.Node {
+	for i := len(elements) - 1; i >= 0; i-- {
+		value = &yamlv3.Node{Kind: yamlv3.MappingNode, Content: []*yamlv3.Node{scalarNode(elements[i].Key), value}}
+	}
+	return value
+}
+
+func pathString(elements []templates.PathElement) string {
+	var path strings.Builder
+	for i, element := range elements {
+		if i > 0 && !(element.IsIndex && element.Key == "") {
+			path.WriteString(".")
+		}
+		path.WriteString(element.String())
+	}
+	return path.String()
+}
@@ -0,0 +1,388 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const editShuttleYaml = `plan: ../plan
+# the vars of the service
+vars:
+  # name of the service
+  service: api # keep this
+  docker:
+    image: api
+    replicas: 1
+
+  tags: [a, b]
+  labels: {team: squad, tier: backend}
+  services:
+    - name: api
+      ports:
+        - 80
+        - 443
+    - name: worker
+  description: |
+    multi
+    line
+scripts:
+  build:
+    actions:
+      - shell: echo build
+`
+
+func TestSetVar(t *testing.T) {
+	tt := []struct {
+		name     string
+		content  string
+		path     string
+		value    interface{}
+		expected string
+		err      string
+	}{
+		{
+			name:    "existing scalar",
+			content: editShuttleYaml,
+			path:    "service",
+			value:   "web",
+			expected: `plan: ../plan
+# the vars of the service
+vars:
+  # name of the service
+  service: web # keep this
+  docker:
+`,
+		},
+		{
+			name:    "nested scalar",
+			content: editShuttleYaml,
+			path:    "docker.replicas",
+			value:   3,
+			expected: `  docker:
+    image: api
+    replicas: 3
+
+  tags: [a, b]
+`,
+		},
+		{
+			name:    "new key",
+			content: editShuttleYaml,
+			path:    "docker.tag",
+			value:   "v1",
+			expected: `  docker:
+    image: api
+    replicas: 1
+    tag: v1
+
+  tags: [a, b]
+`,
+		},
+		{
+			name:    "new maps",
+			content: editShuttleYaml,
+			path:    "k8s.resources.cpu",
+			value:   "100m",
+			expected: `  description: |
+    multi
+    line
+  k8s:
+    resources:
+      cpu: 100m
+scripts:
+`,
+		},
+		{
+			name:    "list index",
+			content: editShuttleYaml,
+			path:    "services[0].ports[-1]",
+			value:   8443,
+			expected: `    - name: api
+      ports:
+        - 80
+        - 8443
+    - name: worker
+`,
+		},
+		{
+			name:    "key of list element",
+			content: editShuttleYaml,
+			path:    "services.1.replicas",
+			value:   2,
+			expected: `    - name: worker
+      replicas: 2
+  description: |
+`,
+		},
+		{
+			name:    "first key of list element",
+			content: editShuttleYaml,
+			path:    "services.1.name",
+			value:   "jobs",
+			expected: `        - 443
+    - name: jobs
+  description: |
+`,
+		},
+		{
+			name:    "flow map",
+			content: editShuttleYaml,
+			path:    "labels.tier",
+			value:   "frontend",
+			expected: `  tags: [a, b]
+  labels: {team: squad, tier: frontend}
+  services:
+`,
+		},
+		{
+			name:    "list value",
+			content: editShuttleYaml,
+			path:    "docker.image",
+			value:   []interface{}{"api", "worker"},
+			expected: `  docker:
+    image:
+      - api
+      - worker
+    replicas: 1
+`,
+		},
+		{
+			name:    "multi line scalar",
+			content: editShuttleYaml,
+			path:    "description",
+			value:   "single",
+			expected: `    - name: worker
+  description: single
+scripts:
+`,
+		},
+		{
+			name:    "no vars",
+			content: "plan: false\n",
+			path:    "docker.image",
+			value:   "api",
+			expected: `plan: false
+vars:
+  docker:
+    image: api
+`,
+		},
+		{
+			name:    "empty vars",
+			content: "plan: false\nvars:\n",
+			path:    "service",
+			value:   "api",
+			expected: `plan: false
+vars:
+  service: api
+`,
+		},
+		{
+			name:    "scalar replaced by map",
+			content: editShuttleYaml,
+			path:    "service.name",
+			value:   "api",
+			expected: `  # name of the service
+  service: # keep this
+    name: api
+  docker:
+`,
+		},
+		{
+			name:    "strings looking like numbers and booleans",
+			content: "vars:\n  tag: v1\n",
+			path:    "tag",
+			value:   ParseValue("1.20"),
+			expected: `vars:
+  tag: "1.20"
+`,
+		},
+		{
+			name:    "yaml 1.1 boolean",
+			content: "vars:\n  answer: yes\n",
+			path:    "answer",
+			value:   ParseValue("no"),
+			expected: `vars:
+  answer: "no"
+`,
+		},
+		{
+			name:    "leading zero",
+			content: "vars:\n  code: 1\n",
+			path:    "code",
+			value:   ParseValue("010"),
+			expected: `vars:
+  code: "010"
+`,
+		},
+		{
+			name:    "after kept block scalar",
+			content: "vars:\n  a: 1\n  b: |+\n    x\n\n\nscripts: {}\n",
+			path:    "c",
+			value:   2,
+			expected: `vars:
+  a: 1
+  b: |+
+    x
+
+
+  c: 2
+scripts: {}
+`,
+		},
+		{
+			name:    "after folded block scalar",
+			content: "vars:\n  a: >\n    folded\n    lines\n\n    more\nscripts: {}\n",
+			path:    "c",
+			value:   2,
+			expected: `vars:
+  a: >
+    folded
+    lines
+
+    more
+  c: 2
+scripts: {}
+`,
+		},
+		{
+			name:    "index out of range",
+			content: editShuttleYaml,
+			path:    "services[2].name",
+			err:     "services[2]: index out of range of 2 elements",
+		},
+		{
+			name:    "new list element",
+			content: "vars:\n  service: api\n",
+			path:    "tags[0]",
+			err:     "tags[0]: can't create list element [0]",
+		},
+		{
+			name:    "wildcard",
+			content: editShuttleYaml,
+			path:    "services.*.name",
+			err:     "services.*.name: wildcards can't be set",
+		},
+		{
+			name:    "key of list",
+			content: editShuttleYaml,
+			path:    "tags.name",
+			err:     "tags.name: tags is a list",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			updated, err := SetVar([]byte(tc.content), tc.path, tc.value)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Contains(t, string(updated), tc.expected)
+		})
+	}
+}
+
+func TestSetVar_unchangedLines(t *testing.T) {
+	updated, err := SetVar([]byte(editShuttleYaml), "docker.replicas", 3)
+	assert.NoError(t, err)
+	assert.Equal(t, strings.Replace(editShuttleYaml, "replicas: 1", "replicas: 3", 1), string(updated))
+}
+
+func TestUnsetVar(t *testing.T) {
+	tt := []struct {
+		name     string
+		content  string
+		path     string
+		found    bool
+		expected string
+	}{
+		{
+			name:    "scalar with comment",
+			content: editShuttleYaml,
+			path:    "service",
+			found:   true,
+			expected: `# the vars of the service
+vars:
+  docker:
+`,
+		},
+		{
+			name:    "map",
+			content: editShuttleYaml,
+			path:    "docker",
+			found:   true,
+			expected: `  service: api # keep this
+
+  tags: [a, b]
+`,
+		},
+		{
+			name:    "last key of map",
+			content: "vars:\n  docker:\n    image: api\n  service: api\n",
+			path:    "docker.image",
+			found:   true,
+			expected: `vars:
+  docker: {}
+  service: api
+`,
+		},
+		{
+			name:    "list element",
+			content: editShuttleYaml,
+			path:    "services[0].ports[0]",
+			found:   true,
+			expected: `    - name: api
+      ports:
+        - 443
+    - name: worker
+`,
+		},
+		{
+			name:    "flow map",
+			content: editShuttleYaml,
+			path:    "labels.team",
+			found:   true,
+			expected: `  tags: [a, b]
+  labels: {tier: backend}
+  services:
+`,
+		},
+		{
+			name:    "first key of list element",
+			content: editShuttleYaml,
+			path:    "services[0].name",
+			found:   true,
+			expected: `  services:
+    - ports:
+        - 80
+        - 443
+    - name: worker
+`,
+		},
+		{
+			name:     "missing",
+			content:  editShuttleYaml,
+			path:     "docker.tag",
+			found:    false,
+			expected: editShuttleYaml,
+		},
+		{
+			name:     "no vars",
+			content:  "plan: false\n",
+			path:     "docker.tag",
+			found:    false,
+			expected: "plan: false\n",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			updated, found, err := UnsetVar([]byte(tc.content), tc.path)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.found, found, "found")
+			assert.Contains(t, string(updated), tc.expected)
+		})
+	}
+}
@@ -21,6 +21,18 @@ type ShuttleConfig struct {
 	PlanRaw   interface{}                  `yaml:"plan"`
 	Variables DynamicYaml                  `yaml:"vars"`
 	Scripts   map[string]ShuttlePlanScript `yaml:"scripts"`
+	Templates []ShuttleTemplate            `yaml:"templates"`
+}
+
+// ShuttleTemplate is a file or directory rendered by shuttle template and committed to the project. They are checked
+// by shuttle template check-all
+type ShuttleTemplate struct {
+	Template string `yaml:"template"`
+	// Output is relative to the project, and is a file for file templates and a directory for directory templates
+	Output string            `yaml:"output"`
+	Args   map[string]string `yaml:"args"`
+	// Delims are the left and right delimiters split by ',', like the --delims flag of shuttle template
+	Delims string `yaml:"delims"`
 }
 
 // ShuttleProjectContext describes the context of the project using shuttle
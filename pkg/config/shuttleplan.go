@@ -6,7 +6,9 @@ import (
 	"path"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/lunarway/shuttle/pkg/copy"
 	"github.com/lunarway/shuttle/pkg/errors"
@@ -30,7 +32,32 @@ type ShuttleScriptArgs struct {
 	// Type optionally describes the kind of argument. When set to "bool" the
 	// flag can be passed without an explicit value (e.g. "--silent" is treated
 	// as "--silent=true"). Empty defaults to a string argument.
+	//
+	// The types int, float, duration and stringSlice are validated when the
+	// flag is set.
 	Type string `yaml:"type"`
+	// Default is used when the argument isn't set
+	Default string `yaml:"default"`
+}
+
+// ValidateValue reports whether value can be parsed as the arguments Type
+func (a ShuttleScriptArgs) ValidateValue(value string) error {
+	var err error
+	switch a.Type {
+	case "bool":
+		_, err = strconv.ParseBool(value)
+	case "int":
+		_, err = strconv.ParseInt(value, 10, 64)
+	case "float":
+		_, err = strconv.ParseFloat(value, 64)
+	case "duration":
+		_, err = time.ParseDuration(value)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid %s value '%s' for argument %s", a.Type, value, a.Name)
+	}
+
+	return nil
 }
 
 func (a ShuttleScriptArgs) String() string {
@@ -39,6 +66,9 @@ func (a ShuttleScriptArgs) String() string {
 	if a.Required {
 		s.WriteString(" (required)")
 	}
+	if a.Default != "" {
+		fmt.Fprintf(&s, " (default: %s)", a.Default)
+	}
 	if len(a.Description) != 0 {
 		fmt.Fprintf(&s, "  %s", a.Description)
 	}
@@ -57,6 +87,13 @@ type ShuttlePlanConfiguration struct {
 	Vars          map[string]interface{}       `yaml:"vars"`
 	Documentation string                       `yaml:"documentation"`
 	Scripts       map[string]ShuttlePlanScript `yaml:"scripts"`
+	Templates     ShuttlePlanTemplates         `yaml:"templates"`
+}
+
+// ShuttlePlanTemplates configures shuttle template in projects using the plan
+type ShuttlePlanTemplates struct {
+	// Strict fails templates on missing vars, unless overridden with --strict=false
+	Strict bool `yaml:"strict"`
 }
 
 // ShuttlePlan struct describes a plan
@@ -0,0 +1,272 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+	yamlv3 "gopkg.in/yaml.v3"
+
+	"github.com/lunarway/shuttle/pkg/templates"
+)
+
+// Values are extra vars merged over the vars of shuttle.yaml, i.e. to render templates for a specific environment
+type Values struct {
+	// Files are yaml files deep merged over the vars in order
+	Files []string
+	// Set are path=value overrides applied after Files, i.e. docker.replicas=3
+	Set []string
+	// SetString are path=value overrides applied after Set, with the values kept as strings, i.e. docker.tag=true
+	SetString []string
+}
+
+// Apply returns a copy of vars with the values merged over it. vars is returned as is if there are no values
+func (v Values) Apply(vars DynamicYaml) (DynamicYaml, error) {
+	if len(v.Files) == 0 && len(v.Set) == 0 && len(v.SetString) == 0 {
+		return vars, nil
+	}
+
+	merged := mergeVars(DynamicYaml{}, vars)
+	for _, file := range v.Files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read values file: %w", err)
+		}
+		var values DynamicYaml
+		if err := yaml.Unmarshal(content, &values); err != nil {
+			return nil, fmt.Errorf("failed to parse values file '%s': %w", file, err)
+		}
+		merged = mergeVars(merged, values)
+	}
+
+	for _, set := range v.Set {
+		path, value, err := ParseSet(set)
+		if err != nil {
+			return nil, err
+		}
+		if err := setVar(merged, path, value); err != nil {
+			return nil, fmt.Errorf("--set '%s': %w", set, err)
+		}
+	}
+	for _, set := range v.SetString {
+		path, value, err := parseSet(set, "--set-string")
+		if err != nil {
+			return nil, err
+		}
+		if err := setVar(merged, path, value); err != nil {
+			return nil, fmt.Errorf("--set-string '%s': %w", set, err)
+		}
+	}
+
+	return merged, nil
+}
+
+// ParseSet parses a path=value override, where the path is parsed by templates.ParsePath and the value is typed by
+// ParseValue
+func ParseSet(set string) ([]templates.PathElement, interface{}, error) {
+	path, raw, err := parseSet(set, "--set")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return path, ParseValue(raw), nil
+}
+
+// parseSet parses a path=value override of flag, keeping the value as is
+func parseSet(set, flag string) ([]templates.PathElement, string, error) {
+	key, raw, ok := cutPath(set)
+	if !ok || key == "" {
+		return nil, "", fmt.Errorf("%s '%s' must be in the form path=value", flag, set)
+	}
+	path, err := templates.ParsePath(key)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s '%s' has an invalid path: %w", flag, set, err)
+	}
+	for _, element := range path {
+		if element.Wildcard {
+			return nil, "", fmt.Errorf("%s '%s' can't set wildcards", flag, set)
+		}
+	}
+
+	return path, raw, nil
+}
+
+// cutPath cuts set around the first = outside of quoted keys, so keys like annotations."a=b" are supported
+func cutPath(set string) (string, string, bool) {
+	quoted := false
+	for i := 0; i < len(set); i++ {
+		switch {
+		case quoted && set[i] == '\\':
+			i++
+		case set[i] == '"':
+			quoted = !quoted
+		case !quoted && set[i] == '=':
+			return set[:i], set[i+1:], true
+		}
+	}
+	return set, "", false
+}
+
+// ParseValue types raw like in yaml, so numbers, booleans, null and flow sequences like [a, b] are parsed, while
+// anything else is kept as a string. Scalars are only typed if they are written the way yaml writes the typed value,
+// so versions like 1.20 and codes like 010 or no are kept as strings rather than changed to 1.2, 8 or false
+func ParseValue(raw string) interface{} {
+	if raw == "" {
+		return ""
+	}
+	var document yamlv3.Node
+	if err := yamlv3.Unmarshal([]byte(raw), &document); err != nil || len(document.Content) == 0 {
+		return raw
+	}
+	node := document.Content[0]
+	switch node.Kind {
+	case yamlv3.ScalarNode:
+		return scalarValue(node)
+	case yamlv3.SequenceNode:
+		if value, ok := sequenceValue(node); ok {
+			return value
+		}
+		return raw
+	default:
+		// i.e. "key: value" is a string rather than a map
+		return raw
+	}
+}
+
+// scalarValue returns the typed value of node, or its text if the value isn't written as its text
+func scalarValue(node *yamlv3.Node) interface{} {
+	var value interface{}
+	if err := node.Decode(&value); err != nil {
+		return node.Value
+	}
+	if _, ok := value.(string); ok {
+		return value
+	}
+	written, err := yamlv3.Marshal(value)
+	if err != nil || strings.TrimSuffix(string(written), "\n") != node.Value {
+		return node.Value
+	}
+	return value
+}
+
+func sequenceValue(node *yamlv3.Node) ([]interface{}, bool) {
+	values := make([]interface{}, 0, len(node.Content))
+	for _, item := range node.Content {
+		switch item.Kind {
+		case yamlv3.ScalarNode:
+			values = append(values, scalarValue(item))
+		case yamlv3.SequenceNode:
+			value, ok := sequenceValue(item)
+			if !ok {
+				return nil, false
+			}
+			values = append(values, value)
+		default:
+			return nil, false
+		}
+	}
+	return values, true
+}
+
+// mergeVars deep merges src over dst. Maps are copied, so the result can be changed without changing either
+func mergeVars(dst, src DynamicYaml) DynamicYaml {
+	for key, value := range src {
+		dst[key] = mergeValue(dst[key], value)
+	}
+	return dst
+}
+
+func mergeValue(dst, src interface{}) interface{} {
+	srcMap, ok := asMap(src)
+	if !ok {
+		return src
+	}
+
+	result := make(map[interface{}]interface{})
+	if dstMap, ok := asMap(dst); ok {
+		for key, value := range dstMap {
+			result[key] = mergeValue(nil, value)
+		}
+	}
+	for key, value := range srcMap {
+		result[key] = mergeValue(result[key], value)
+	}
+	return result
+}
+
+// asMap returns the maps of both yaml and shuttle.yaml vars as the map type decoded by yaml
+func asMap(value interface{}) (map[interface{}]interface{}, bool) {
+	switch m := value.(type) {
+	case map[interface{}]interface{}:
+		return m, true
+	case map[string]interface{}:
+		result := make(map[interface{}]interface{}, len(m))
+		for key, value := range m {
+			result[key] = value
+		}
+		return result, true
+	default:
+		return nil, false
+	}
+}
+
+// setVar sets the var at path, replacing any value that isn't a map or list on the way. List elements are set by
+// index, but can't be added
+func setVar(vars DynamicYaml, path []templates.PathElement, value interface{}) error {
+	first := path[0]
+	if first.IsIndex && first.Key == "" {
+		return fmt.Errorf("vars are a map, not a list")
+	}
+	updated, err := setValue(vars[first.Key], path[1:], value)
+	if err != nil {
+		return err
+	}
+	vars[first.Key] = updated
+	return nil
+}
+
+// setValue returns current with value set at path
+func setValue(current interface{}, path []templates.PathElement, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	element := path[0]
+
+	if list, ok := current.([]interface{}); ok && element.IsIndex {
+		index := element.Index
+		if index < 0 {
+			index += len(list)
+		}
+		if index < 0 || index >= len(list) {
+			return nil, fmt.Errorf("index %s out of range of %d elements", element, len(list))
+		}
+		// lists aren't copied by mergeVars, so they are copied before they are changed
+		updated := append([]interface{}{}, list...)
+		item, err := setValue(updated[index], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		updated[index] = item
+		return updated, nil
+	}
+	if element.IsIndex && element.Key == "" {
+		return nil, fmt.Errorf("can't set index %s of a value that isn't a list", element)
+	}
+
+	m, ok := current.(map[interface{}]interface{})
+	if !ok {
+		m = make(map[interface{}]interface{})
+	}
+	var key interface{} = element.Key
+	// yaml decodes numeric keys as ints
+	if _, ok := m[element.Index]; ok && element.IsIndex {
+		key = element.Index
+	}
+	item, err := setValue(m[key], path[1:], value)
+	if err != nil {
+		return nil, err
+	}
+	m[key] = item
+	return m, nil
+}
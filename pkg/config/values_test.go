@@ -0,0 +1,183 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lunarway/shuttle/pkg/templates"
+)
+
+func TestValues_Apply(t *testing.T) {
+	vars := func() DynamicYaml {
+		return DynamicYaml{
+			"service": "shuttle",
+			"ports":   []interface{}{80, 443},
+			"docker": map[interface{}]interface{}{
+				"image":    "shuttle",
+				"replicas": 1,
+			},
+		}
+	}
+
+	tt := []struct {
+		name   string
+		values Values
+		vars   DynamicYaml
+		err    string
+	}{
+		{
+			name:   "no values",
+			values: Values{},
+			vars:   vars(),
+		},
+		{
+			name:   "file",
+			values: Values{Files: []string{"testdata/values/prod.yaml"}},
+			vars: DynamicYaml{
+				"service": "shuttle",
+				"env":     "prod",
+				"ports":   []interface{}{80, 443},
+				"docker": map[interface{}]interface{}{
+					"image":    "shuttle",
+					"replicas": 3,
+				},
+			},
+		},
+		{
+			name: "set after file",
+			values: Values{
+				Files: []string{"testdata/values/prod.yaml"},
+				Set:   []string{"docker.replicas=5", "docker.debug=true", "service.name=api", "tags=[a, b]", "empty="},
+			},
+			vars: DynamicYaml{
+				"service": map[interface{}]interface{}{
+					"name": "api",
+				},
+				"env":   "prod",
+				"ports": []interface{}{80, 443},
+				"tags":  []interface{}{"a", "b"},
+				"empty": "",
+				"docker": map[interface{}]interface{}{
+					"image":    "shuttle",
+					"replicas": 5,
+					"debug":    true,
+				},
+			},
+		},
+		{
+			name: "set string",
+			values: Values{
+				Set:       []string{"docker.replicas=2", "docker.tag=1.20"},
+				SetString: []string{"docker.replicas=3", "docker.debug=true"},
+			},
+			vars: DynamicYaml{
+				"service": "shuttle",
+				"ports":   []interface{}{80, 443},
+				"docker": map[interface{}]interface{}{
+					"image":    "shuttle",
+					"replicas": "3",
+					"tag":      "1.20",
+					"debug":    "true",
+				},
+			},
+		},
+		{
+			name: "set list elements and quoted keys",
+			values: Values{
+				Set: []string{"ports[-1]=8443", `annotations."lunar.tech/owner"=squad`},
+			},
+			vars: DynamicYaml{
+				"service": "shuttle",
+				"docker": map[interface{}]interface{}{
+					"image":    "shuttle",
+					"replicas": 1,
+				},
+				"ports": []interface{}{80, 8443},
+				"annotations": map[interface{}]interface{}{
+					"lunar.tech/owner": "squad",
+				},
+			},
+		},
+		{
+			name:   "set index out of range",
+			values: Values{Set: []string{"ports[2]=1"}},
+			err:    "--set 'ports[2]=1': index [2] out of range of 2 elements",
+		},
+		{
+			name:   "set index of map",
+			values: Values{Set: []string{"docker[0]=1"}},
+			err:    "--set 'docker[0]=1': can't set index [0] of a value that isn't a list",
+		},
+		{
+			name:   "set wildcard",
+			values: Values{Set: []string{"ports.*=1"}},
+			err:    "--set 'ports.*=1' can't set wildcards",
+		},
+		{
+			name:   "set invalid path",
+			values: Values{Set: []string{"docker.=1"}},
+			err:    "--set 'docker.=1' has an invalid path: path docker.: ends with .",
+		},
+		{
+			name:   "missing file",
+			values: Values{Files: []string{"testdata/values/unknown.yaml"}},
+			err:    "failed to read values file: open testdata/values/unknown.yaml: no such file or directory",
+		},
+		{
+			name:   "set without value",
+			values: Values{Set: []string{"docker.replicas"}},
+			err:    "--set 'docker.replicas' must be in the form path=value",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			original := vars()
+
+			merged, err := tc.values.Apply(original)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.vars, merged)
+			assert.Equal(t, vars(), original, "vars must not be changed")
+		})
+	}
+}
+
+func TestParseSet(t *testing.T) {
+	tt := []struct {
+		input string
+		path  string
+		value interface{}
+	}{
+		{input: "a=1", path: "a", value: 1},
+		{input: "a.b=1.5", path: "a.b", value: 1.5},
+		{input: "a=false", path: "a", value: false},
+		{input: "a=null", path: "a", value: nil},
+		{input: "a=b=c", path: "a", value: "b=c"},
+		{input: "a=key: value", path: "a", value: "key: value"},
+		{input: "a='1'", path: "a", value: "1"},
+		{input: "a=1.20", path: "a", value: "1.20"},
+		{input: "a=no", path: "a", value: "no"},
+		{input: "a=010", path: "a", value: "010"},
+		{input: "a=0x10", path: "a", value: "0x10"},
+		{input: "a=~", path: "a", value: "~"},
+		{input: "a=[1, 1.20, b]", path: "a", value: []interface{}{1, "1.20", "b"}},
+		{input: "services[0].name=b", path: "services[0].name", value: "b"},
+		{input: `annotations."a.b=c"=d`, path: `annotations."a.b=c"`, value: "d"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.input, func(t *testing.T) {
+			path, value, err := ParseSet(tc.input)
+
+			require.NoError(t, err)
+			expected, err := templates.ParsePath(tc.path)
+			require.NoError(t, err)
+			assert.Equal(t, expected, path)
+			assert.Equal(t, tc.value, value)
+		})
+	}
+}
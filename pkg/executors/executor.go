@@ -2,6 +2,7 @@ package executors
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
@@ -32,6 +33,11 @@ type ScriptExecutionContext struct {
 	Script     config.ShuttlePlanScript
 	Project    config.ShuttleProjectContext
 	Args       map[string]string
+	// ProvidedArgs are the names of the Args given when running the script. The others hold their default, or are
+	// empty if they have none
+	ProvidedArgs map[string]bool
+	// Outputs are the JSON values returned by golang actions run earlier in the script, by action name
+	Outputs map[string]json.RawMessage
 }
 
 // ActionExecutionContext gives context to the execution of Actions in a script
@@ -47,6 +53,7 @@ func (r *Registry) Execute(
 	p config.ShuttleProjectContext,
 	command string,
 	args map[string]string,
+	providedArgs map[string]bool,
 	validateArgs bool,
 ) error {
 	script, ok := p.Scripts[command]
@@ -55,10 +62,12 @@ func (r *Registry) Execute(
 	}
 
 	scriptContext := ScriptExecutionContext{
-		ScriptName: command,
-		Script:     script,
-		Project:    p,
-		Args:       args,
+		ScriptName:   command,
+		Script:       script,
+		Project:      p,
+		Args:         args,
+		ProvidedArgs: providedArgs,
+		Outputs:      make(map[string]json.RawMessage),
 	}
 
 	for actionIndex, action := range script.Actions {
@@ -3,6 +3,7 @@ package executors
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"testing"
 	"time"
@@ -178,7 +179,7 @@ func TestExecute(t *testing.T) {
 						},
 					},
 				},
-			}, "test", nil, true)
+			}, "test", nil, nil, true)
 
 			if tc.err != nil {
 				assert.EqualError(t, err, tc.err.Error())
@@ -189,6 +190,32 @@ func TestExecute(t *testing.T) {
 	}
 }
 
+func TestExecute_outputs(t *testing.T) {
+	// fakes a golang action returning a value
+	taskExecutor := func(action config.ShuttleAction) (Executor, bool) {
+		return func(ctx context.Context, ui *ui.UI, context ActionExecutionContext) error {
+			context.ScriptContext.Outputs[action.Task] = json.RawMessage(`"v1.2.3"`)
+			return nil
+		}, action.Task != ""
+	}
+	registry := NewRegistry(ShellExecutor, taskExecutor)
+
+	err := registry.Execute(context.Background(), config.ShuttleProjectContext{
+		ProjectPath: ".",
+		UI:          ui.Create(&bytes.Buffer{}, &bytes.Buffer{}),
+		Scripts: map[string]config.ShuttlePlanScript{
+			"release": {
+				Actions: []config.ShuttleAction{
+					{Task: "version"},
+					{Shell: `test "$SHUTTLE_OUTPUT_VERSION" = "v1.2.3"`},
+				},
+			},
+		},
+	}, "release", nil, nil, true)
+
+	assert.NoError(t, err)
+}
+
 // TestExecute_contextCancellation tests that scripts are closed when the
 // context is cancelled.
 func TestExecute_contextCancellation(t *testing.T) {
@@ -220,7 +247,7 @@ func TestExecute_contextCancellation(t *testing.T) {
 
 	registry := NewRegistry(ShellExecutor)
 
-	err := registry.Execute(ctx, projectContext, "serve", nil, true)
+	err := registry.Execute(ctx, projectContext, "serve", nil, nil, true)
 	assert.EqualError(t, err, context.Canceled.Error())
 
 	// sadly we need to give the docker some time before "docker ps" shows the
@@ -248,3 +275,23 @@ func runningDockerImages(t *testing.T, imageName string) []string {
 	}
 	return nil
 }
+
+func TestTaskArgs(t *testing.T) {
+	actionContext := ActionExecutionContext{
+		Action: config.ShuttleAction{Task: "deploy"},
+		ScriptContext: ScriptExecutionContext{
+			Args: map[string]string{
+				"replicas": "",
+				"tag":      "",
+				"env":      "dev",
+				"verbose":  "true",
+			},
+			ProvidedArgs: map[string]bool{
+				"tag":     true,
+				"verbose": true,
+			},
+		},
+	}
+
+	assert.Equal(t, []string{"deploy", "--env=dev", "--tag=", "--verbose=true"}, taskArgs(actionContext))
+}
@@ -0,0 +1,248 @@
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lunarway/shuttle/pkg/executors/golang/shuttlefolder"
+)
+
+const (
+	envCacheDir     = "SHUTTLE_GOLANG_ACTIONS_CACHE_DIR"
+	envCacheMaxSize = "SHUTTLE_GOLANG_ACTIONS_CACHE_MAX_SIZE"
+
+	// DefaultMaxSize is the size limit of the cache, unless overridden by SHUTTLE_GOLANG_ACTIONS_CACHE_MAX_SIZE
+	DefaultMaxSize int64 = 2 << 30
+)
+
+// Cache is a content addressed store of actions binaries shared between all projects of a user. Binaries are keyed by
+// the hash of the sources they are built from, see matcher.GetHash, so identical plans are only compiled once
+type Cache struct {
+	dir     string
+	maxSize int64
+}
+
+// Entry is a binary stored in the cache
+type Entry struct {
+	Key      string
+	Path     string
+	Size     int64
+	LastUsed time.Time
+}
+
+// New returns a cache in dir, which is limited to maxSize bytes. Least recently used binaries are evicted beyond the
+// limit
+func New(dir string, maxSize int64) *Cache {
+	return &Cache{
+		dir:     dir,
+		maxSize: maxSize,
+	}
+}
+
+// Default returns the user level cache in SHUTTLE_GOLANG_ACTIONS_CACHE_DIR, or shuttle/actions in the users cache dir,
+// i.e. ~/.cache/shuttle/actions
+func Default() (*Cache, error) {
+	maxSize := DefaultMaxSize
+	if raw := os.Getenv(envCacheMaxSize); raw != "" {
+		size, err := ParseSize(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", envCacheMaxSize, err)
+		}
+		maxSize = size
+	}
+
+	dir := os.Getenv(envCacheDir)
+	if dir == "" {
+		userCacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to find user cache dir: %w", err)
+		}
+		dir = path.Join(userCacheDir, "shuttle", "actions")
+	}
+
+	return New(dir, maxSize), nil
+}
+
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+func (c *Cache) MaxSize() int64 {
+	return c.maxSize
+}
+
+// Key is the file name of the binary built from hash
+func Key(hash string) string {
+	return path.Base(shuttlefolder.CalculateBinaryPath("", hash))
+}
+
+// Lookup returns the path of the binary built from hash, if it is cached
+func (c *Cache) Lookup(hash string) (string, bool, error) {
+	binaryPath := path.Join(c.dir, Key(hash))
+	if _, err := os.Stat(binaryPath); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+
+	// the modification time is used to evict the least recently used binaries
+	now := time.Now()
+	if err := os.Chtimes(binaryPath, now, now); err != nil {
+		return "", false, err
+	}
+
+	return binaryPath, true, nil
+}
+
+// Store copies the binary built from hash into the cache, and evicts binaries beyond the size limit
+func (c *Cache) Store(hash string, binaryPath string) error {
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return err
+	}
+
+	// binaries are written to a temporary file first, so concurrent shuttles never see a partial binary
+	tmp, err := os.CreateTemp(c.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := copyFile(tmp, binaryPath); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o755); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path.Join(c.dir, Key(hash))); err != nil {
+		return err
+	}
+
+	_, err = c.Prune(c.maxSize)
+	return err
+}
+
+// List returns the cached binaries, most recently used first
+func (c *Cache) List() ([]Entry, error) {
+	dirEntries, err := os.ReadDir(c.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(dirEntries))
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() || !strings.HasPrefix(dirEntry.Name(), shuttlefolder.TaskBinaryPrefix+"-") {
+			continue
+		}
+
+		info, err := dirEntry.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		entries = append(entries, Entry{
+			Key:      dirEntry.Name(),
+			Path:     path.Join(c.dir, dirEntry.Name()),
+			Size:     info.Size(),
+			LastUsed: info.ModTime(),
+		})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].LastUsed.After(entries[j].LastUsed)
+	})
+
+	return entries, nil
+}
+
+// Prune evicts the least recently used binaries until the cache is at most maxSize bytes, and returns the removed
+// entries. A maxSize of 0 empties the cache
+func (c *Cache) Prune(maxSize int64) ([]Entry, error) {
+	entries, err := c.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var size int64
+	removed := make([]Entry, 0)
+	for _, entry := range entries {
+		size += entry.Size
+		if size <= maxSize {
+			continue
+		}
+
+		if err := os.Remove(entry.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return removed, err
+		}
+		removed = append(removed, entry)
+	}
+
+	return removed, nil
+}
+
+func copyFile(dst io.Writer, src string) error {
+	file, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(dst, file)
+	return err
+}
+
+// ParseSize parses a size in bytes, optionally suffixed by K, M or G as powers of 1024, i.e. 500M
+func ParseSize(input string) (int64, error) {
+	raw := strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(input)), "B")
+
+	multiplier := int64(1)
+	switch {
+	case strings.HasSuffix(raw, "K"):
+		multiplier = 1 << 10
+	case strings.HasSuffix(raw, "M"):
+		multiplier = 1 << 20
+	case strings.HasSuffix(raw, "G"):
+		multiplier = 1 << 30
+	}
+	if multiplier != 1 {
+		raw = raw[:len(raw)-1]
+	}
+
+	size, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || size < 0 {
+		return 0, fmt.Errorf("invalid size '%s', expected i.e. 500M or 2G", input)
+	}
+
+	return size * multiplier, nil
+}
+
+// FormatSize formats size in bytes with the largest fitting unit of ParseSize
+func FormatSize(size int64) string {
+	switch {
+	case size >= 1<<30:
+		return fmt.Sprintf("%.1fG", float64(size)/(1<<30))
+	case size >= 1<<20:
+		return fmt.Sprintf("%.1fM", float64(size)/(1<<20))
+	case size >= 1<<10:
+		return fmt.Sprintf("%.1fK", float64(size)/(1<<10))
+	default:
+		return fmt.Sprintf("%dB", size)
+	}
+}
@@ -0,0 +1,102 @@
+package cache_test
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lunarway/shuttle/pkg/executors/golang/cache"
+)
+
+const (
+	hash      = "h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="
+	otherHash = "h1:Dh6GC1ZwdmVsXqUBPsqCWS+XZUgjTqIiYUJcCPrGOD0="
+)
+
+func writeBinary(t *testing.T, size int) string {
+	t.Helper()
+
+	binaryPath := path.Join(t.TempDir(), "actions")
+	require.NoError(t, os.WriteFile(binaryPath, make([]byte, size), 0o755))
+	return binaryPath
+}
+
+func TestCacheStoreLookup(t *testing.T) {
+	sut := cache.New(t.TempDir(), 1<<20)
+
+	_, ok, err := sut.Lookup(hash)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, sut.Store(hash, writeBinary(t, 10)))
+
+	cachedPath, ok, err := sut.Lookup(hash)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, path.Join(sut.Dir(), cache.Key(hash)), cachedPath)
+
+	info, err := os.Stat(cachedPath)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o755), info.Mode().Perm())
+}
+
+func TestCacheSizeLimit(t *testing.T) {
+	sut := cache.New(t.TempDir(), 15)
+
+	require.NoError(t, sut.Store(hash, writeBinary(t, 10)))
+	// make the first binary the least recently used
+	old := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(path.Join(sut.Dir(), cache.Key(hash)), old, old))
+
+	require.NoError(t, sut.Store(otherHash, writeBinary(t, 10)))
+
+	entries, err := sut.List()
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, cache.Key(otherHash), entries[0].Key)
+}
+
+func TestCachePrune(t *testing.T) {
+	sut := cache.New(t.TempDir(), 1<<20)
+	require.NoError(t, sut.Store(hash, writeBinary(t, 10)))
+	require.NoError(t, sut.Store(otherHash, writeBinary(t, 10)))
+
+	removed, err := sut.Prune(0)
+
+	require.NoError(t, err)
+	assert.Len(t, removed, 2)
+	entries, err := sut.List()
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestParseSize(t *testing.T) {
+	tt := []struct {
+		input    string
+		expected int64
+		err      bool
+	}{
+		{input: "1024", expected: 1024},
+		{input: "500M", expected: 500 << 20},
+		{input: "2G", expected: 2 << 30},
+		{input: "10kb", expected: 10 << 10},
+		{input: "many", err: true},
+		{input: "-1", err: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.input, func(t *testing.T) {
+			actual, err := cache.ParseSize(tc.input)
+
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
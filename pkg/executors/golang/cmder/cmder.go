@@ -1,22 +1,41 @@
 package cmder
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"os/signal"
 	"reflect"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/lunarway/shuttle/pkg/executors/golang/executer"
+	"github.com/lunarway/shuttle/pkg/executors/golang/protocol"
+	"github.com/lunarway/shuttle/pkg/sdk"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
 )
 
 var (
 	ErrNoHelp = errors.New("cmd failed with exit 1")
 )
 
+// Formats of the --output flag of actions returning a value
+const (
+	OutputJSON = "json"
+	OutputYAML = "yaml"
+)
+
+// outputFlag selects the format of the value returned by an action
+const outputFlag = "output"
+
 type RootCmd struct {
 	Cmds []*Cmd
 }
@@ -33,8 +52,16 @@ func (rc *RootCmd) AddCmds(cmd ...*Cmd) *RootCmd {
 	return rc
 }
 
+// Execute runs the action of os.Args and exits on failure. The context of the action is cancelled on SIGINT or
+// SIGTERM, which shuttle forwards when it is stopped, so actions are able to clean up
 func (rc *RootCmd) Execute() {
-	if err := rc.TryExecute(os.Args[1:]); err != nil {
+	// the signals stay caught until the action returns, so a repeated Ctrl-C doesn't interrupt its cleanup. Shuttle
+	// kills the action if it doesn't return within a grace period
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	err := rc.TryExecuteContext(ctx, os.Args[1:])
+	stop()
+
+	if err != nil {
 		if errors.Is(err, ErrNoHelp) {
 			os.Exit(1)
 		} else {
@@ -44,6 +71,11 @@ func (rc *RootCmd) Execute() {
 }
 
 func (rc *RootCmd) TryExecute(args []string) error {
+	return rc.TryExecuteContext(context.Background(), args)
+}
+
+// TryExecuteContext runs the action of args with ctx
+func (rc *RootCmd) TryExecuteContext(ctx context.Context, args []string) error {
 	rootcmd := &cobra.Command{Use: "actions"}
 
 	rootcmd.AddCommand(
@@ -59,20 +91,43 @@ func (rc *RootCmd) TryExecute(args []string) error {
 			Run: func(cmd *cobra.Command, args []string) {
 				actions := executer.NewActions()
 				for _, cmd := range rc.Cmds {
-					args := make([]executer.ActionArg, 0)
+					params, err := cmd.params()
+					if err != nil {
+						log.Fatal(err)
+					}
 
-					for _, arg := range cmd.Args {
+					args := make([]executer.ActionArg, 0)
+					for _, param := range params {
+						for _, flag := range param.flags {
+							args = append(args, executer.ActionArg{
+								Name:        flag.name,
+								Type:        flag.argType,
+								Description: flag.help,
+								Optional:    !flag.required,
+								Default:     flag.def,
+							})
+						}
+					}
+					if cmd.valueIndex() >= 0 {
 						args = append(args, executer.ActionArg{
-							Name: arg.Name,
+							Name:        outputFlag,
+							Type:        ArgTypeString,
+							Description: outputHelp,
+							Optional:    true,
+							Default:     OutputJSON,
 						})
 					}
 
 					actions.Actions[cmd.Name] = executer.Action{
-						Args: args,
+						Description: cmd.Description,
+						Args:        args,
 					}
 				}
 
-				rawJson, err := json.Marshal(actions)
+				rawJson, err := json.Marshal(executer.Inquiry{
+					Handshake: protocol.NewHandshake(),
+					Actions:   actions,
+				})
 				if err != nil {
 					log.Fatal(err)
 				}
@@ -90,70 +145,217 @@ func (rc *RootCmd) TryExecute(args []string) error {
 
 	for _, cmd := range rc.Cmds {
 		cmd := cmd
-		parameters := make([]string, len(cmd.Args))
+		params, err := cmd.params()
+		if err != nil {
+			return err
+		}
 
+		short, _, _ := strings.Cut(cmd.Description, "\n")
 		cobracmd := &cobra.Command{
-			Use: cmd.Name,
+			Use:   cmd.Name,
+			Short: short,
+			Long:  cmd.Description,
 
 			// We don't want to show the full usage, instead just show the error
 			SilenceUsage: true,
-
-			RunE: func(cobracmd *cobra.Command, args []string) error {
-				if err := cobracmd.ParseFlags(args); err != nil {
-					log.Println(err)
-					return ErrNoHelp
+			// Errors are printed by the action, or reported to shuttle in the result
+			SilenceErrors: true,
+		}
+		binding, err := bind(cobracmd.Flags(), params)
+		if err != nil {
+			return fmt.Errorf("%s: %w", cmd.Name, err)
+		}
+		valueIndex := cmd.valueIndex()
+		var output string
+		if valueIndex >= 0 {
+			if cobracmd.Flags().Lookup(outputFlag) != nil {
+				return fmt.Errorf("%s: arg %s clashes with the --%s flag of actions returning a value", cmd.Name, outputFlag, outputFlag)
+			}
+			cobracmd.Flags().StringVar(&output, outputFlag, OutputJSON, outputHelp)
+		}
+		for _, param := range params {
+			for _, flag := range param.flags {
+				if flag.required {
+					_ = cobracmd.MarkFlagRequired(flag.name)
 				}
+			}
+		}
 
-				inputs := make([]reflect.Value, 0, len(cmd.Args)+1)
-				inputs = append(inputs, reflect.ValueOf(context.Background()))
-				for _, arg := range parameters {
-					inputs = append(inputs, reflect.ValueOf(arg))
+		cobracmd.RunE = func(cobracmd *cobra.Command, args []string) error {
+			if err := cobracmd.ParseFlags(args); err != nil {
+				log.Println(err)
+				return ErrNoHelp
+			}
+
+			if valueIndex >= 0 && output != OutputJSON && output != OutputYAML {
+				fmt.Fprintf(cobracmd.ErrOrStderr(), "invalid --%s '%s', expected %s or %s\n", outputFlag, output, OutputJSON, OutputYAML)
+				return ErrNoHelp
+			}
+
+			ctx := cobracmd.Context()
+			eventsFile, err := eventsFromEnv()
+			if err != nil {
+				fmt.Fprintln(cobracmd.ErrOrStderr(), err)
+				return ErrNoHelp
+			}
+			// events may be captured through the context by tests, see cmdertest
+			events, _ := sdk.EventsFrom(ctx)
+			if eventsFile != nil {
+				defer eventsFile.Close()
+				events = protocol.NewEncoder(eventsFile)
+				ctx = sdk.WithEvents(ctx, events)
+			}
+
+			// the shuttle context is only required by actions declaring it as a parameter,
+			// others may read it through sdk.ShuttleContextFrom if available
+			sc, err := sdk.ShuttleContextFromEnv()
+			if err == nil {
+				ctx = sdk.WithShuttleContext(ctx, sc)
+				binding.injectShuttleContext(sc)
+			} else if binding.needsShuttleContext() {
+				fmt.Fprintln(cobracmd.ErrOrStderr(), err)
+				return ErrNoHelp
+			}
+
+			values := binding.args(cobracmd.Flags())
+			inputs := make([]reflect.Value, 0, len(values)+1)
+			inputs = append(inputs, reflect.ValueOf(ctx))
+			inputs = append(inputs, values...)
+
+			start := time.Now()
+			returnValues := reflect.
+				ValueOf(cmd.Func).
+				Call(inputs)
+
+			var actionErr error
+			for _, val := range returnValues {
+				if val.Type().Implements(errorType) {
+					err, ok := val.Interface().(error)
+					if ok && err != nil {
+						actionErr = err
+					}
 				}
+			}
 
-				returnValues := reflect.
-					ValueOf(cmd.Func).
-					Call(inputs)
-
-				if len(returnValues) == 0 {
-					return nil
+			// the value is only reported if the action succeeded
+			var value json.RawMessage
+			if valueIndex >= 0 && actionErr == nil {
+				value, err = json.Marshal(returnValues[valueIndex].Interface())
+				if err != nil {
+					actionErr = fmt.Errorf("failed to serialise the value returned by %s: %w", cmd.Name, err)
+				} else if err := printValue(cobracmd.OutOrStdout(), value, output); err != nil {
+					actionErr = err
 				}
-
-				for _, val := range returnValues {
-					if val.Type().Implements(reflect.TypeOf((*error)(nil)).Elem()) {
-						err, ok := val.Interface().(error)
-						if ok && err != nil {
-							fmt.Fprintln(cobracmd.ErrOrStderr(), err)
-							return ErrNoHelp
-						}
-					}
+			}
+
+			if events != nil {
+				// shuttle formats the error of the result, so it isn't printed here
+				result := &protocol.Result{
+					Action:     cmd.Name,
+					Success:    actionErr == nil,
+					DurationMS: time.Since(start).Milliseconds(),
+					Value:      value,
+				}
+				if actionErr != nil {
+					result.Error = actionErr.Error()
+				}
+				if err := events.Encode(protocol.Event{Type: protocol.EventResult, Result: result}); err != nil {
+					fmt.Fprintf(cobracmd.ErrOrStderr(), "failed to report result to shuttle: %v\n", err)
 				}
+			} else if actionErr != nil {
+				fmt.Fprintln(cobracmd.ErrOrStderr(), actionErr)
+			}
 
-				return nil
-			},
-		}
-		for i, arg := range cmd.Args {
-			cobracmd.Flags().StringVar(&parameters[i], arg.Name, "", "")
-			_ = cobracmd.MarkFlagRequired(arg.Name)
+			if actionErr != nil {
+				return ErrNoHelp
+			}
+
+			return nil
 		}
 
 		rootcmd.AddCommand(cobracmd)
 	}
 
 	rootcmd.SetArgs(args)
-	if err := rootcmd.Execute(); err != nil {
+	if err := rootcmd.ExecuteContext(ctx); err != nil {
 		return err
 	}
 	return nil
 }
 
+const outputHelp = "format of the returned value, json or yaml"
+
+// valueIndex returns the index of the value returned by the action besides its error, or -1 if it only returns an error
+func (c *Cmd) valueIndex() int {
+	funcType := reflect.TypeOf(c.Func)
+	if funcType == nil || funcType.Kind() != reflect.Func {
+		return -1
+	}
+
+	for i := 0; i < funcType.NumOut(); i++ {
+		if !funcType.Out(i).Implements(errorType) {
+			return i
+		}
+	}
+	return -1
+}
+
+// printValue writes the JSON value to w in format. YAML is converted from the JSON, so the json tags of the returned
+// type apply to both formats
+func printValue(w io.Writer, value json.RawMessage, format string) error {
+	if format == OutputYAML {
+		var v any
+		if err := json.Unmarshal(value, &v); err != nil {
+			return err
+		}
+		content, err := yaml.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("failed to format value as yaml: %w", err)
+		}
+		_, err = w.Write(content)
+		return err
+	}
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, value, "", "  "); err != nil {
+		return err
+	}
+	indented.WriteString("\n")
+	_, err := indented.WriteTo(w)
+	return err
+}
+
+// eventsFromEnv opens the file descriptor passed by shuttle in protocol.EventsFDEnv, if shuttle reads events
+func eventsFromEnv() (*os.File, error) {
+	raw := os.Getenv(protocol.EventsFDEnv)
+	if raw == "" {
+		return nil, nil
+	}
+
+	fd, err := strconv.Atoi(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s '%s': %w", protocol.EventsFDEnv, raw, err)
+	}
+
+	return openEventsFD(fd), nil
+}
+
 type Arg struct {
-	Name string
+	Name        string
+	Description string
+	// Optional and Required overrides whether the arg is required, which otherwise depends on its type.
+	// Pointer and bool args are optional, everything else is required
+	Optional bool
+	Required bool
+	// Default is used when the flag isn't set, and makes the arg optional
+	Default string
 }
 
 type Cmd struct {
-	Name string
-	Func any
-	Args []Arg
+	Name        string
+	Description string
+	Func        any
+	Args        []Arg
 }
 
 func NewCmd(name string, f any) *Cmd {
@@ -168,3 +370,15 @@ func WithArgs(cmd *Cmd, argName string) *Cmd {
 	cmd.Args = append(cmd.Args, Arg{Name: argName})
 	return cmd
 }
+
+// WithArg adds an arg including its documentation
+func WithArg(cmd *Cmd, arg Arg) *Cmd {
+	cmd.Args = append(cmd.Args, arg)
+	return cmd
+}
+
+// WithDescription sets the description shown by shuttle ls and shuttle run --help
+func WithDescription(cmd *Cmd, description string) *Cmd {
+	cmd.Description = description
+	return cmd
+}
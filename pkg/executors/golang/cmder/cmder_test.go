@@ -3,11 +3,16 @@ package cmder_test
 import (
 	"context"
 	"errors"
+	"path"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/lunarway/shuttle/pkg/executors/golang/cmder"
+	"github.com/lunarway/shuttle/pkg/executors/golang/cmder/cmdertest"
+	"github.com/lunarway/shuttle/pkg/sdk"
 )
 
 func TestCmderWithError(t *testing.T) {
@@ -79,3 +84,346 @@ func TestCmderWithMultipeReturnsErroringInAnyPlace(t *testing.T) {
 
 	assert.ErrorIs(t, err, cmder.ErrNoHelp)
 }
+
+func TestCmderWithTypedArgs(t *testing.T) {
+	var (
+		actualReplicas int
+		actualDryRun   bool
+		actualRatio    float64
+		actualTimeout  time.Duration
+		actualTags     []string
+	)
+	testFunc := cmder.NewCmd("deploy", func(
+		ctx context.Context,
+		replicas int,
+		dryRun bool,
+		ratio float64,
+		timeout time.Duration,
+		tags []string,
+	) error {
+		actualReplicas = replicas
+		actualDryRun = dryRun
+		actualRatio = ratio
+		actualTimeout = timeout
+		actualTags = tags
+		return nil
+	})
+	testFunc = cmder.WithArgs(testFunc, "replicas")
+	testFunc = cmder.WithArgs(testFunc, "dryrun")
+	testFunc = cmder.WithArgs(testFunc, "ratio")
+	testFunc = cmder.WithArgs(testFunc, "timeout")
+	testFunc = cmder.WithArgs(testFunc, "tags")
+
+	err := cmder.NewRoot().AddCmds(testFunc).TryExecute([]string{
+		"deploy",
+		"--replicas=3",
+		"--dryrun",
+		"--ratio=0.5",
+		"--timeout=1m30s",
+		"--tags=a,b",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, actualReplicas)
+	assert.True(t, actualDryRun)
+	assert.Equal(t, 0.5, actualRatio)
+	assert.Equal(t, 90*time.Second, actualTimeout)
+	assert.Equal(t, []string{"a", "b"}, actualTags)
+}
+
+func TestCmderWithInvalidTypedArg(t *testing.T) {
+	testFunc := cmder.NewCmd("deploy", func(ctx context.Context, replicas int) error {
+		return nil
+	})
+	testFunc = cmder.WithArgs(testFunc, "replicas")
+
+	err := cmder.NewRoot().AddCmds(testFunc).TryExecute([]string{"deploy", "--replicas=many"})
+
+	assert.Error(t, err)
+}
+
+func TestCmderWithMissingRequiredArg(t *testing.T) {
+	testFunc := cmder.NewCmd("deploy", func(ctx context.Context, replicas int, dryRun bool) error {
+		return nil
+	})
+	testFunc = cmder.WithArgs(testFunc, "replicas")
+	testFunc = cmder.WithArgs(testFunc, "dryrun")
+
+	err := cmder.NewRoot().AddCmds(testFunc).TryExecute([]string{"deploy", "--dryrun"})
+
+	assert.ErrorContains(t, err, "replicas")
+}
+
+type deployOptions struct {
+	Environment string        `flag:"env" help:"environment to deploy to" required:"true"`
+	Replicas    int           `help:"number of replicas"`
+	Wait        time.Duration `flag:"wait"`
+	Skipped     string        `flag:"-"`
+	unexported  string
+}
+
+func TestCmderWithOptionsStruct(t *testing.T) {
+	var actual deployOptions
+	testFunc := cmder.NewCmd("deploy", func(ctx context.Context, opts deployOptions) error {
+		actual = opts
+		return nil
+	})
+	testFunc = cmder.WithArgs(testFunc, "opts")
+
+	err := cmder.NewRoot().AddCmds(testFunc).TryExecute([]string{
+		"deploy",
+		"--env=prod",
+		"--replicas=2",
+		"--wait=5s",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, deployOptions{
+		Environment: "prod",
+		Replicas:    2,
+		Wait:        5 * time.Second,
+	}, actual)
+
+	err = cmder.NewRoot().AddCmds(testFunc).TryExecute([]string{"deploy", "--replicas=2"})
+	assert.ErrorContains(t, err, "env")
+}
+
+func TestCmderWithUnsupportedType(t *testing.T) {
+	testFunc := cmder.NewCmd("deploy", func(ctx context.Context, values map[string]string) error {
+		return nil
+	})
+	testFunc = cmder.WithArgs(testFunc, "values")
+
+	err := cmder.NewRoot().AddCmds(testFunc).TryExecute([]string{"deploy"})
+
+	assert.ErrorContains(t, err, "unsupported type")
+}
+
+func TestCmderWithOptionalArgs(t *testing.T) {
+	var (
+		actualReplicas *int
+		actualEnv      *string
+		actualTimeout  time.Duration
+	)
+	testFunc := cmder.NewCmd("deploy", func(ctx context.Context, replicas *int, env *string, timeout time.Duration) error {
+		actualReplicas = replicas
+		actualEnv = env
+		actualTimeout = timeout
+		return nil
+	})
+	testFunc = cmder.WithArgs(testFunc, "replicas")
+	testFunc = cmder.WithArgs(testFunc, "env")
+	testFunc = cmder.WithArg(testFunc, cmder.Arg{Name: "timeout", Default: "5m"})
+
+	err := cmder.NewRoot().AddCmds(testFunc).TryExecute([]string{"deploy", "--replicas=2"})
+
+	assert.NoError(t, err)
+	if assert.NotNil(t, actualReplicas) {
+		assert.Equal(t, 2, *actualReplicas)
+	}
+	assert.Nil(t, actualEnv)
+	assert.Equal(t, 5*time.Minute, actualTimeout)
+}
+
+type defaultOptions struct {
+	Replicas int      `default:"3"`
+	Tags     []string `default:"a,b"`
+	Env      *string  `default:"dev"`
+	Region   *string
+}
+
+func TestCmderWithOptionsStructDefaults(t *testing.T) {
+	var actual defaultOptions
+	testFunc := cmder.NewCmd("deploy", func(ctx context.Context, opts defaultOptions) error {
+		actual = opts
+		return nil
+	})
+	testFunc = cmder.WithArgs(testFunc, "opts")
+
+	err := cmder.NewRoot().AddCmds(testFunc).TryExecute([]string{"deploy", "--tags=c"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, actual.Replicas)
+	assert.Equal(t, []string{"c"}, actual.Tags)
+	if assert.NotNil(t, actual.Env) {
+		assert.Equal(t, "dev", *actual.Env)
+	}
+	assert.Nil(t, actual.Region)
+}
+
+func TestCmderWithInvalidDefault(t *testing.T) {
+	testFunc := cmder.NewCmd("deploy", func(ctx context.Context, replicas int) error {
+		return nil
+	})
+	testFunc = cmder.WithArg(testFunc, cmder.Arg{Name: "replicas", Default: "many"})
+
+	err := cmder.NewRoot().AddCmds(testFunc).TryExecute([]string{"deploy"})
+
+	assert.ErrorContains(t, err, "invalid default value")
+}
+
+func TestCmderWithShuttleContext(t *testing.T) {
+	contextFile := path.Join(t.TempDir(), "context.yaml")
+	require.NoError(t, sdk.WriteShuttleContextFile(contextFile, sdk.ShuttleContext{
+		Variables:     map[string]any{"service": "shuttle"},
+		ProjectPath:   "/project",
+		LocalPlanPath: "/project/.shuttle/plan",
+	}))
+	t.Setenv(sdk.ShuttleContextFileEnv, contextFile)
+
+	var (
+		actualParam   sdk.ShuttleContext
+		actualContext sdk.ShuttleContext
+		actualEnv     string
+	)
+	testFunc := cmder.NewCmd("deploy", func(ctx context.Context, sc sdk.ShuttleContext, env string) error {
+		actualParam = sc
+		actualEnv = env
+
+		var err error
+		actualContext, err = sdk.ShuttleContextFrom(ctx)
+		return err
+	})
+	testFunc = cmder.WithArgs(testFunc, "env")
+
+	err := cmder.NewRoot().AddCmds(testFunc).TryExecute([]string{"deploy", "--env=prod"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "prod", actualEnv)
+	assert.Equal(t, "/project", actualParam.ProjectPath)
+	assert.Equal(t, "/project/.shuttle/plan", actualParam.LocalPlanPath)
+	assert.Equal(t, "shuttle", actualParam.Variables["service"])
+	assert.Equal(t, actualParam, actualContext)
+}
+
+func TestCmderWithShuttleContextMissing(t *testing.T) {
+	t.Setenv(sdk.ShuttleContextFileEnv, "")
+
+	called := false
+	testFunc := cmder.NewCmd("deploy", func(ctx context.Context, sc sdk.ShuttleContext) error {
+		called = true
+		return nil
+	})
+
+	err := cmder.NewRoot().AddCmds(testFunc).TryExecute([]string{"deploy"})
+
+	assert.ErrorIs(t, err, cmder.ErrNoHelp)
+	assert.False(t, called)
+}
+
+func TestCmderWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var actual error
+	testFunc := cmder.NewCmd("wait", func(ctx context.Context) error {
+		<-ctx.Done()
+		actual = ctx.Err()
+		return nil
+	})
+
+	err := cmder.NewRoot().AddCmds(testFunc).TryExecuteContext(ctx, []string{"wait"})
+
+	assert.NoError(t, err)
+	assert.ErrorIs(t, actual, context.Canceled)
+}
+
+func TestCmderWithValue(t *testing.T) {
+	type image struct {
+		Name string   `json:"name"`
+		Tags []string `json:"tags,omitempty"`
+	}
+	newRoot := func() *cmder.RootCmd {
+		return cmder.NewRoot().AddCmds(
+			cmder.NewCmd("image", func(ctx context.Context) (*image, error) {
+				return &image{Name: "shuttle", Tags: []string{"latest"}}, nil
+			}),
+			cmder.NewCmd("failing", func(ctx context.Context) (string, error) {
+				return "ignored", errors.New("some-error")
+			}),
+		)
+	}
+
+	t.Run("json", func(t *testing.T) {
+		result := cmdertest.Execute(t, newRoot(), []string{"image"})
+
+		require.NoError(t, result.Err)
+		assert.Equal(t, "{\n  \"name\": \"shuttle\",\n  \"tags\": [\n    \"latest\"\n  ]\n}\n", result.Stdout)
+		assert.JSONEq(t, `{"name":"shuttle","tags":["latest"]}`, string(result.Value))
+	})
+
+	t.Run("yaml", func(t *testing.T) {
+		result := cmdertest.Execute(t, newRoot(), []string{"image", "--output=yaml"})
+
+		require.NoError(t, result.Err)
+		assert.Equal(t, "name: shuttle\ntags:\n- latest\n", result.Stdout)
+	})
+
+	t.Run("invalid format", func(t *testing.T) {
+		result := cmdertest.Execute(t, newRoot(), []string{"image", "--output=xml"})
+
+		assert.ErrorIs(t, result.Err, cmder.ErrNoHelp)
+		assert.Contains(t, result.Stderr, "invalid --output 'xml'")
+	})
+
+	t.Run("failing action", func(t *testing.T) {
+		result := cmdertest.Execute(t, newRoot(), []string{"failing"})
+
+		assert.EqualError(t, result.Err, "some-error")
+		assert.Empty(t, result.Stdout)
+		assert.Nil(t, result.Value)
+	})
+}
+
+func TestCmderWithValueAndOutputArg(t *testing.T) {
+	testFunc := cmder.NewCmd("image", func(ctx context.Context, output string) (string, error) {
+		return output, nil
+	})
+	testFunc = cmder.WithArgs(testFunc, "output")
+
+	err := cmder.NewRoot().AddCmds(testFunc).TryExecute([]string{"image", "--output=json"})
+
+	assert.ErrorContains(t, err, "arg output clashes with the --output flag")
+}
+
+func TestCmderWithUnsupportedValueType(t *testing.T) {
+	type replicas int
+	testFunc := cmder.NewCmd("replicas", func(ctx context.Context) (replicas, error) {
+		return 2, nil
+	})
+
+	err := cmder.NewRoot().AddCmds(testFunc).TryExecute([]string{"replicas"})
+
+	assert.EqualError(t, err, "replicas: returned value has unsupported type cmder_test.replicas, expected a string, map or struct")
+}
+
+func TestCmderWithValuePointer(t *testing.T) {
+	type image struct {
+		Name string `json:"name"`
+	}
+	testFunc := cmder.NewCmd("image", func(ctx context.Context) (*image, error) {
+		return &image{Name: "shuttle"}, nil
+	})
+
+	err := cmder.NewRoot().AddCmds(testFunc).TryExecute([]string{"image"})
+
+	assert.NoError(t, err)
+}
+
+func TestCmderWithDuplicateFlags(t *testing.T) {
+	type buildOptions struct {
+		Environment string `flag:"env"`
+	}
+	type deployOptions struct {
+		Env string
+	}
+	testFunc := cmder.NewCmd("deploy", func(ctx context.Context, build buildOptions, deploy deployOptions) error {
+		return nil
+	})
+	testFunc = cmder.WithArgs(testFunc, "build")
+	testFunc = cmder.WithArgs(testFunc, "deploy")
+
+	err := cmder.NewRoot().AddCmds(testFunc).TryExecute([]string{"deploy"})
+
+	assert.EqualError(t, err, "deploy: flag --env declared twice")
+}
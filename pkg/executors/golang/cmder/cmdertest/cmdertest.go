@@ -0,0 +1,182 @@
+// Package cmdertest runs golang actions in tests like shuttle does, i.e. with the newShuttleRootCmd generated for
+// tests run by shuttle actions test:
+//
+//	func TestDeploy(t *testing.T) {
+//		result := cmdertest.Execute(t, newShuttleRootCmd(), []string{"deploy", "--replicas=2"})
+//
+//		assert.NoError(t, result.Err)
+//		assert.Contains(t, result.Stdout, "deployed 2 replicas")
+//	}
+//
+// Execute replaces os.Stdout, os.Stderr and environment variables while the action runs, so tests using it must not
+// run in parallel. Output written directly to the file descriptors, i.e. by println or processes started by the action,
+// isn't captured.
+package cmdertest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path"
+	"sync"
+	"testing"
+
+	"github.com/lunarway/shuttle/pkg/executors/golang/cmder"
+	"github.com/lunarway/shuttle/pkg/executors/golang/protocol"
+	"github.com/lunarway/shuttle/pkg/sdk"
+)
+
+// Result is the outcome of an action run by Execute
+type Result struct {
+	Stdout string
+	Stderr string
+	// Events are the logs, progress and result reported by the action through the sdk package
+	Events []protocol.Event
+	// Err is the error returned by the action, or the error of cmder if the arguments are invalid
+	Err error
+	// Value is the JSON of the value returned by actions with a (T, error) signature
+	Value json.RawMessage
+}
+
+// DecodeValue unmarshals the value returned by the action into v
+func (r Result) DecodeValue(v any) error {
+	if r.Value == nil {
+		return errors.New("the action did not return a value")
+	}
+	return json.Unmarshal(r.Value, v)
+}
+
+// Logs returns the messages logged by the action at level
+func (r Result) Logs(level protocol.Level) []string {
+	logs := make([]string, 0)
+	for _, event := range r.Events {
+		if event.Type == protocol.EventLog && event.Level == level {
+			logs = append(logs, event.Message)
+		}
+	}
+	return logs
+}
+
+// Option configures Execute
+type Option func(*options)
+
+type options struct {
+	ctx            context.Context
+	shuttleContext *sdk.ShuttleContext
+}
+
+// WithContext runs the action with ctx, i.e. to test cancellation
+func WithContext(ctx context.Context) Option {
+	return func(o *options) {
+		o.ctx = ctx
+	}
+}
+
+// WithShuttleContext passes sc to the action, instead of the context of the project passed by shuttle actions test
+func WithShuttleContext(sc sdk.ShuttleContext) Option {
+	return func(o *options) {
+		o.shuttleContext = &sc
+	}
+}
+
+// Execute runs the action of args with root, and captures its output and events
+func Execute(t testing.TB, root *cmder.RootCmd, args []string, opts ...Option) Result {
+	t.Helper()
+
+	o := options{
+		ctx: context.Background(),
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.shuttleContext != nil {
+		contextFile := path.Join(t.TempDir(), "shuttle-context.yaml")
+		if err := sdk.WriteShuttleContextFile(contextFile, *o.shuttleContext); err != nil {
+			t.Fatalf("failed to write shuttle context: %v", err)
+		}
+		t.Setenv(sdk.ShuttleContextFileEnv, contextFile)
+	}
+
+	// events are captured through the context rather than a file descriptor passed by shuttle
+	t.Setenv(protocol.EventsFDEnv, "")
+	var events bytes.Buffer
+	ctx := sdk.WithEvents(o.ctx, protocol.NewEncoder(&events))
+
+	var result Result
+	result.Stdout, result.Stderr = capture(t, func() {
+		result.Err = root.TryExecuteContext(ctx, args)
+	})
+
+	decoder := protocol.NewDecoder(&events)
+	for {
+		event, err := decoder.Decode()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				t.Fatalf("failed to read events: %v", err)
+			}
+			break
+		}
+		result.Events = append(result.Events, event)
+	}
+
+	for _, event := range result.Events {
+		if event.Type != protocol.EventResult || event.Result == nil {
+			continue
+		}
+		if !event.Result.Success {
+			result.Err = errors.New(event.Result.Error)
+		}
+		result.Value = event.Result.Value
+	}
+
+	return result
+}
+
+// capture returns what fn writes to os.Stdout and os.Stderr
+func capture(t testing.TB, fn func()) (string, string) {
+	t.Helper()
+
+	stdoutReader, stdoutWriter, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create stdout pipe: %v", err)
+	}
+	stderrReader, stderrWriter, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("failed to create stderr pipe: %v", err)
+	}
+
+	var (
+		stdout, stderr bytes.Buffer
+		wg             sync.WaitGroup
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, _ = io.Copy(&stdout, stdoutReader)
+	}()
+	go func() {
+		defer wg.Done()
+		_, _ = io.Copy(&stderr, stderrReader)
+	}()
+
+	originalStdout, originalStderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = stdoutWriter, stderrWriter
+	func() {
+		defer func() {
+			os.Stdout, os.Stderr = originalStdout, originalStderr
+			stdoutWriter.Close()
+			stderrWriter.Close()
+		}()
+		fn()
+	}()
+
+	wg.Wait()
+	stdoutReader.Close()
+	stderrReader.Close()
+
+	return stdout.String(), stderr.String()
+}
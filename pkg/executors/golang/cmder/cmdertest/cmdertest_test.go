@@ -0,0 +1,69 @@
+package cmdertest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/lunarway/shuttle/pkg/executors/golang/cmder"
+	"github.com/lunarway/shuttle/pkg/executors/golang/cmder/cmdertest"
+	"github.com/lunarway/shuttle/pkg/executors/golang/protocol"
+	"github.com/lunarway/shuttle/pkg/sdk"
+)
+
+func newRoot() *cmder.RootCmd {
+	deploy := cmder.NewCmd("deploy", func(ctx context.Context, sc sdk.ShuttleContext, replicas int) error {
+		fmt.Printf("deploying %v with %d replicas\n", sc.Variables["service"], replicas)
+		fmt.Fprintln(os.Stderr, "to stderr")
+		sdk.Infof(ctx, "rolling out")
+		if replicas == 0 {
+			return errors.New("no replicas")
+		}
+		return nil
+	})
+	deploy = cmder.WithArgs(deploy, "replicas")
+
+	version := cmder.NewCmd("version", func(ctx context.Context) (map[string]string, error) {
+		return map[string]string{"version": "v1.2.3"}, nil
+	})
+
+	return cmder.NewRoot().AddCmds(deploy, version)
+}
+
+func TestExecute(t *testing.T) {
+	sc := sdk.ShuttleContext{Variables: map[string]any{"service": "shuttle"}}
+
+	t.Run("captures output and events", func(t *testing.T) {
+		result := cmdertest.Execute(t, newRoot(), []string{"deploy", "--replicas=2"}, cmdertest.WithShuttleContext(sc))
+
+		assert.NoError(t, result.Err)
+		assert.Equal(t, "deploying shuttle with 2 replicas\n", result.Stdout)
+		assert.Equal(t, "to stderr\n", result.Stderr)
+		assert.Equal(t, []string{"rolling out"}, result.Logs(protocol.LevelInfo))
+	})
+
+	t.Run("returns the error of the action", func(t *testing.T) {
+		result := cmdertest.Execute(t, newRoot(), []string{"deploy", "--replicas=0"}, cmdertest.WithShuttleContext(sc))
+
+		assert.EqualError(t, result.Err, "no replicas")
+	})
+
+	t.Run("returns the value of the action", func(t *testing.T) {
+		result := cmdertest.Execute(t, newRoot(), []string{"version"})
+
+		assert.NoError(t, result.Err)
+		var value map[string]string
+		assert.NoError(t, result.DecodeValue(&value))
+		assert.Equal(t, map[string]string{"version": "v1.2.3"}, value)
+	})
+
+	t.Run("returns invalid arguments", func(t *testing.T) {
+		result := cmdertest.Execute(t, newRoot(), []string{"deploy", "--replicas=many"}, cmdertest.WithShuttleContext(sc))
+
+		assert.Error(t, result.Err)
+	})
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package cmder
+
+import "os"
+
+// openEventsFD returns nil as shuttle only passes the events pipe on unix, see executer.eventsSupported
+func openEventsFD(fd int) *os.File {
+	return nil
+}
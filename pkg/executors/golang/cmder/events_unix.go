@@ -0,0 +1,16 @@
+//go:build unix
+
+package cmder
+
+import (
+	"os"
+	"syscall"
+)
+
+// openEventsFD opens the events pipe inherited from shuttle
+func openEventsFD(fd int) *os.File {
+	// processes started by the action must not keep the pipe open after the action is done
+	syscall.CloseOnExec(fd)
+
+	return os.NewFile(uintptr(fd), "shuttle-events")
+}
@@ -0,0 +1,69 @@
+//go:build unix
+
+package cmder_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lunarway/shuttle/pkg/executors/golang/cmder"
+	"github.com/lunarway/shuttle/pkg/executors/golang/protocol"
+	"github.com/lunarway/shuttle/pkg/sdk"
+)
+
+func TestCmderWithEvents(t *testing.T) {
+	events, eventsWriter, err := os.Pipe()
+	require.NoError(t, err)
+	defer events.Close()
+
+	// the action closes its file descriptor when done, like the binary run by shuttle
+	fd, err := syscall.Dup(int(eventsWriter.Fd()))
+	require.NoError(t, err)
+	require.NoError(t, eventsWriter.Close())
+	t.Setenv(protocol.EventsFDEnv, strconv.Itoa(fd))
+
+	testFunc := cmder.NewCmd("deploy", func(ctx context.Context) error {
+		sdk.Infof(ctx, "deploying %s", "shuttle")
+		sdk.Progress(ctx, "rolling out", 1, 2)
+		return errors.New("some-error")
+	})
+
+	err = cmder.NewRoot().AddCmds(testFunc).TryExecute([]string{"deploy"})
+	assert.ErrorIs(t, err, cmder.ErrNoHelp)
+
+	decoder := protocol.NewDecoder(events)
+	actual := make([]protocol.Event, 0)
+	for {
+		event, err := decoder.Decode()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		event.Time = time.Time{}
+		actual = append(actual, event)
+	}
+
+	require.Len(t, actual, 3)
+	assert.Equal(t, protocol.Event{
+		Type:    protocol.EventLog,
+		Level:   protocol.LevelInfo,
+		Message: "deploying shuttle",
+	}, actual[0])
+	assert.Equal(t, protocol.Event{
+		Type:     protocol.EventProgress,
+		Progress: &protocol.Progress{Step: "rolling out", Current: 1, Total: 2},
+	}, actual[1])
+	assert.Equal(t, protocol.EventResult, actual[2].Type)
+	assert.Equal(t, "deploy", actual[2].Result.Action)
+	assert.False(t, actual[2].Result.Success)
+	assert.Equal(t, "some-error", actual[2].Result.Error)
+}
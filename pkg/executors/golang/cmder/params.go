@@ -0,0 +1,349 @@
+package cmder
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+
+	"github.com/lunarway/shuttle/pkg/sdk"
+)
+
+// Arg types reported through lsjson
+const (
+	ArgTypeString      = "string"
+	ArgTypeInt         = "int"
+	ArgTypeFloat       = "float"
+	ArgTypeBool        = "bool"
+	ArgTypeDuration    = "duration"
+	ArgTypeStringSlice = "stringSlice"
+)
+
+var (
+	contextType  = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType    = reflect.TypeOf((*error)(nil)).Elem()
+	durationType = reflect.TypeOf(time.Duration(0))
+
+	shuttleContextType = reflect.TypeOf(sdk.ShuttleContext{})
+)
+
+// param is a single input of an action function after the context
+type param struct {
+	typ   reflect.Type
+	flags []flag
+	// shuttleContext params are injected instead of being bound to flags
+	shuttleContext bool
+}
+
+// flag is bound to either a param, or a field of an options struct param
+type flag struct {
+	name     string
+	argType  string
+	help     string
+	required bool
+	// def is the default value, parsed as if passed on the command line
+	def   string
+	field []int
+}
+
+// params maps the parameters of cmd.Func to flags. Scalar parameters become a single flag named by cmd.Args,
+// options struct parameters become a flag per exported field. sdk.ShuttleContext parameters aren't registered
+// as args, as they are injected
+func (c *Cmd) params() ([]param, error) {
+	funcType := reflect.TypeOf(c.Func)
+	if funcType == nil || funcType.Kind() != reflect.Func {
+		return nil, fmt.Errorf("%s: action is not a function", c.Name)
+	}
+	if funcType.NumIn() == 0 || funcType.In(0) != contextType {
+		return nil, fmt.Errorf("%s: first parameter must be a context.Context", c.Name)
+	}
+	if i := c.valueIndex(); i >= 0 && !isSupportedValueType(funcType.Out(i)) {
+		return nil, fmt.Errorf("%s: returned value has unsupported type %s, expected a string, map or struct", c.Name, funcType.Out(i))
+	}
+
+	argParams := 0
+	for i := 1; i < funcType.NumIn(); i++ {
+		if funcType.In(i) != shuttleContextType {
+			argParams++
+		}
+	}
+	if argParams != len(c.Args) {
+		return nil, fmt.Errorf(
+			"%s: function has %d parameters, but %d args were registered",
+			c.Name,
+			argParams,
+			len(c.Args),
+		)
+	}
+
+	params := make([]param, 0, funcType.NumIn()-1)
+	args := c.Args
+	for i := 1; i < funcType.NumIn(); i++ {
+		paramType := funcType.In(i)
+		if paramType == shuttleContextType {
+			params = append(params, param{
+				typ:            paramType,
+				shuttleContext: true,
+			})
+			continue
+		}
+
+		arg := args[0]
+		args = args[1:]
+
+		if argType, ok := argTypeOf(paramType); ok {
+			// a missing bool flag is simply false, and a missing pointer is nil
+			required := argType != ArgTypeBool && paramType.Kind() != reflect.Pointer
+			if arg.Optional || arg.Default != "" {
+				required = false
+			}
+			if arg.Required {
+				required = true
+			}
+
+			params = append(params, param{
+				typ: paramType,
+				flags: []flag{{
+					name:     arg.Name,
+					argType:  argType,
+					help:     arg.Description,
+					required: required,
+					def:      arg.Default,
+				}},
+			})
+			continue
+		}
+
+		if paramType.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("%s: parameter %s has unsupported type %s", c.Name, arg.Name, paramType)
+		}
+
+		flags, err := structFlags(paramType)
+		if err != nil {
+			return nil, fmt.Errorf("%s: parameter %s: %w", c.Name, arg.Name, err)
+		}
+		params = append(params, param{
+			typ:   paramType,
+			flags: flags,
+		})
+	}
+
+	// pflag panics when a flag is registered twice, i.e. by two options structs
+	declared := make(map[string]bool)
+	for _, p := range params {
+		for _, f := range p.flags {
+			if declared[f.name] {
+				return nil, fmt.Errorf("%s: flag --%s declared twice", c.Name, f.name)
+			}
+			declared[f.name] = true
+		}
+	}
+
+	return params, nil
+}
+
+// isSupportedValueType allows the values actions return besides their error to be strings, maps and structs, or
+// pointers to them, as they are serialised as JSON
+func isSupportedValueType(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String, reflect.Map, reflect.Struct:
+		return true
+	default:
+		return false
+	}
+}
+
+// structFlags supports the following tags on exported fields of an options struct:
+//
+//	flag:"name"       the flag name, defaults to the lowercased field name. "-" skips the field
+//	help:"some text"  the description of the flag
+//	required:"true"   the flag has to be set
+//	default:"value"   the value used when the flag isn't set
+//
+// Pointer fields are left nil when the flag isn't set
+func structFlags(structType reflect.Type) ([]flag, error) {
+	flags := make([]flag, 0, structType.NumField())
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := field.Tag.Get("flag")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+
+		argType, ok := argTypeOf(field.Type)
+		if !ok {
+			return nil, fmt.Errorf("field %s has unsupported type %s", field.Name, field.Type)
+		}
+
+		flags = append(flags, flag{
+			name:     name,
+			argType:  argType,
+			help:     field.Tag.Get("help"),
+			required: field.Tag.Get("required") == "true",
+			def:      field.Tag.Get("default"),
+			field:    field.Index,
+		})
+	}
+
+	return flags, nil
+}
+
+func argTypeOf(t reflect.Type) (string, bool) {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == durationType {
+		return ArgTypeDuration, true
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return ArgTypeString, true
+	case reflect.Int, reflect.Int64:
+		return ArgTypeInt, true
+	case reflect.Float64:
+		return ArgTypeFloat, true
+	case reflect.Bool:
+		return ArgTypeBool, true
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.String {
+			return ArgTypeStringSlice, true
+		}
+	}
+
+	return "", false
+}
+
+// binding holds the values of the action parameters while flags are parsed
+type binding struct {
+	values          []reflect.Value
+	pointers        []pointerFlag
+	shuttleContexts []reflect.Value
+}
+
+// pointerFlag is only assigned to its parameter or field when the flag is set
+type pointerFlag struct {
+	name       string
+	target     reflect.Value
+	storage    reflect.Value
+	hasDefault bool
+}
+
+// bind registers the flags of params on flagSet. The values are populated when flagSet is parsed
+func bind(flagSet *pflag.FlagSet, params []param) (*binding, error) {
+	b := &binding{}
+	for _, p := range params {
+		value := reflect.New(p.typ).Elem()
+		if p.shuttleContext {
+			b.shuttleContexts = append(b.shuttleContexts, value)
+		}
+		for _, f := range p.flags {
+			target := value
+			if f.field != nil {
+				target = value.FieldByIndex(f.field)
+			}
+
+			storage := target.Addr()
+			if target.Kind() == reflect.Pointer {
+				storage = reflect.New(target.Type().Elem())
+				b.pointers = append(b.pointers, pointerFlag{
+					name:       f.name,
+					target:     target,
+					storage:    storage,
+					hasDefault: f.def != "",
+				})
+			}
+			bindFlag(flagSet, f, storage)
+
+			if f.def != "" {
+				if err := setDefault(flagSet, f); err != nil {
+					return nil, err
+				}
+			}
+		}
+		b.values = append(b.values, value)
+	}
+
+	return b, nil
+}
+
+// args returns the values the action should be called with, once flagSet has been parsed
+func (b *binding) args(flagSet *pflag.FlagSet) []reflect.Value {
+	for _, p := range b.pointers {
+		if p.hasDefault || flagSet.Changed(p.name) {
+			p.target.Set(p.storage)
+		}
+	}
+
+	return b.values
+}
+
+// needsShuttleContext reports whether the action has sdk.ShuttleContext parameters
+func (b *binding) needsShuttleContext() bool {
+	return len(b.shuttleContexts) > 0
+}
+
+// injectShuttleContext sets the sdk.ShuttleContext parameters to sc
+func (b *binding) injectShuttleContext(sc sdk.ShuttleContext) {
+	for _, value := range b.shuttleContexts {
+		value.Set(reflect.ValueOf(sc))
+	}
+}
+
+func setDefault(flagSet *pflag.FlagSet, f flag) error {
+	registered := flagSet.Lookup(f.name)
+
+	var err error
+	if slice, ok := registered.Value.(pflag.SliceValue); ok {
+		// Set would make the first value on the command line append to the default
+		err = slice.Replace(strings.Split(f.def, ","))
+	} else {
+		err = registered.Value.Set(f.def)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid default value '%s' for %s: %w", f.def, f.name, err)
+	}
+	registered.DefValue = registered.Value.String()
+
+	return nil
+}
+
+// bindFlag converts ptr to a pointer of the underlying type, so named types such as `type Env string` are supported
+func bindFlag(flagSet *pflag.FlagSet, f flag, ptr reflect.Value) {
+	as := func(example any) any {
+		return ptr.Convert(reflect.TypeOf(example)).Interface()
+	}
+
+	switch f.argType {
+	case ArgTypeDuration:
+		flagSet.DurationVar(as((*time.Duration)(nil)).(*time.Duration), f.name, 0, f.help)
+	case ArgTypeString:
+		flagSet.StringVar(as((*string)(nil)).(*string), f.name, "", f.help)
+	case ArgTypeInt:
+		if ptr.Elem().Kind() == reflect.Int64 {
+			flagSet.Int64Var(as((*int64)(nil)).(*int64), f.name, 0, f.help)
+		} else {
+			flagSet.IntVar(as((*int)(nil)).(*int), f.name, 0, f.help)
+		}
+	case ArgTypeFloat:
+		flagSet.Float64Var(as((*float64)(nil)).(*float64), f.name, 0, f.help)
+	case ArgTypeBool:
+		flagSet.BoolVar(as((*bool)(nil)).(*bool), f.name, false, f.help)
+	case ArgTypeStringSlice:
+		flagSet.StringSliceVar(as((*[]string)(nil)).(*[]string), f.name, nil, f.help)
+	}
+}
@@ -3,10 +3,14 @@ package codegen
 import (
 	"context"
 	"embed"
-	"html/template"
+	"fmt"
 	"os"
 	"path"
+	"strconv"
 	"strings"
+	"text/template"
+
+	"golang.org/x/mod/modfile"
 
 	"github.com/lunarway/shuttle/pkg/executors/golang/discover"
 	"github.com/lunarway/shuttle/pkg/executors/golang/parser"
@@ -15,30 +19,127 @@ import (
 //go:embed templates/mainFile.tmpl
 var mainFileTmpl embed.FS
 
+type mainFile struct {
+	Imports   []mainFileImport
+	Functions []*parser.Function
+}
+
+// mainFileImport is a subpackage of the actions module containing namespaced actions
+type mainFileImport struct {
+	Alias string
+	Path  string
+}
+
 func GenerateMainFile(
 	ctx context.Context,
 	shuttlelocaldir string,
 	actions *discover.ActionsDiscovered,
 	functions []*parser.Function,
 ) error {
+	imports, err := packageImports(shuttlelocaldir, actions, functions)
+	if err != nil {
+		return err
+	}
+
 	tmpmainfile := path.Join(shuttlelocaldir, "tmp/main.go")
 
 	file, err := os.Create(tmpmainfile)
 	if err != nil {
 		return err
 	}
+	defer file.Close()
 
 	tmpl := template.
 		Must(
 			template.
 				New("mainFile.tmpl").
 				Funcs(map[string]any{
-					"lower": strings.ToLower,
+					"lower":   strings.ToLower,
+					"quote":   strconv.Quote,
+					"funcRef": funcRef,
+					"varName": varName,
 				}).
 				ParseFS(mainFileTmpl, "templates/mainFile.tmpl"),
 		)
 
-	err = tmpl.Execute(file, functions)
+	err = tmpl.Execute(file, mainFile{
+		Imports:   imports,
+		Functions: functions,
+	})
 
 	return err
 }
+
+// packageImports returns the subpackages declaring at least one of functions. Packages without actions, i.e. helper
+// libraries, are imported by the packages using them, so importing them in main would fail as unused
+func packageImports(
+	shuttlelocaldir string,
+	actions *discover.ActionsDiscovered,
+	functions []*parser.Function,
+) ([]mainFileImport, error) {
+	namespaces := make(map[string]bool)
+	for _, f := range functions {
+		if f.Namespace != "" {
+			namespaces[f.Namespace] = true
+		}
+	}
+	if len(namespaces) == 0 {
+		return nil, nil
+	}
+
+	goMod, err := os.ReadFile(path.Join(shuttlelocaldir, "tmp", "go.mod"))
+	if err != nil {
+		return nil, fmt.Errorf("namespaced actions require a go.mod in the actions directory: %w", err)
+	}
+	modulePath := modfile.ModulePath(goMod)
+	if modulePath == "" {
+		return nil, fmt.Errorf("failed to find module path of actions go.mod")
+	}
+
+	imports := make([]mainFileImport, 0, len(namespaces))
+	aliasDirs := make(map[string]string, len(namespaces))
+	for _, pkg := range actions.Packages {
+		if !namespaces[pkg.Dir] {
+			continue
+		}
+		alias := packageAlias(pkg.Dir)
+		if dir, ok := aliasDirs[alias]; ok {
+			return nil, fmt.Errorf("actions packages %s and %s are both imported as %s, rename one of them", dir, pkg.Dir, alias)
+		}
+		aliasDirs[alias] = pkg.Dir
+		imports = append(imports, mainFileImport{
+			Alias: alias,
+			Path:  path.Join(modulePath, pkg.Dir),
+		})
+	}
+
+	return imports, nil
+}
+
+// packageAlias is the import name of a subpackage, as directory names aren't necessarily valid identifiers
+func packageAlias(dir string) string {
+	return "actions_" + strings.Map(func(r rune) rune {
+		if r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return '_'
+	}, dir)
+}
+
+// funcRef is the expression referring to the function from the generated main package
+func funcRef(f *parser.Function) string {
+	if f.Namespace == "" {
+		return f.Name
+	}
+
+	return packageAlias(f.Namespace) + "." + f.Name
+}
+
+// varName is the variable holding the command of the function in the generated main package
+func varName(f *parser.Function) string {
+	if f.Namespace == "" {
+		return strings.ToLower(f.Name) + "cmd"
+	}
+
+	return strings.ToLower(packageAlias(f.Namespace)+"_"+f.Name) + "cmd"
+}
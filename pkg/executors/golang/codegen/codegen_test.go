@@ -0,0 +1,100 @@
+package codegen
+
+import (
+	"context"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lunarway/shuttle/pkg/executors/golang/discover"
+	"github.com/lunarway/shuttle/pkg/executors/golang/parser"
+)
+
+func TestGenerateMainFileNamespaces(t *testing.T) {
+	t.Parallel()
+
+	shuttlelocaldir := t.TempDir()
+	require.NoError(t, os.MkdirAll(path.Join(shuttlelocaldir, "tmp"), 0o755))
+	require.NoError(t, os.WriteFile(path.Join(shuttlelocaldir, "tmp", "go.mod"), []byte("module actions\n"), 0o644))
+
+	err := GenerateMainFile(
+		context.Background(),
+		shuttlelocaldir,
+		&discover.ActionsDiscovered{
+			Packages: []discover.ActionsPackage{
+				{Dir: "db/schema-v2", Files: []string{"apply.go"}},
+				{Dir: "lib", Files: []string{"helpers.go"}},
+			},
+		},
+		[]*parser.Function{
+			{Name: "Build"},
+			{Name: "Apply", Namespace: "db/schema-v2"},
+		},
+	)
+	require.NoError(t, err)
+
+	mainFile, err := os.ReadFile(path.Join(shuttlelocaldir, "tmp", "main.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(mainFile), `actions_db_schema_v2 "actions/db/schema-v2"`)
+	assert.NotContains(t, string(mainFile), `"actions/lib"`, "packages without actions must not be imported")
+	assert.Contains(t, string(mainFile), `buildcmd := cmder.NewCmd("build", Build)`)
+	assert.Contains(t, string(mainFile), `func newShuttleRootCmd() *cmder.RootCmd {`)
+	assert.Contains(
+		t,
+		string(mainFile),
+		`actions_db_schema_v2_applycmd := cmder.NewCmd("db:schema-v2:apply", actions_db_schema_v2.Apply)`,
+	)
+}
+
+func TestGenerateMainFileHelperPackagesWithoutGoMod(t *testing.T) {
+	t.Parallel()
+
+	shuttlelocaldir := t.TempDir()
+	require.NoError(t, os.MkdirAll(path.Join(shuttlelocaldir, "tmp"), 0o755))
+
+	err := GenerateMainFile(
+		context.Background(),
+		shuttlelocaldir,
+		&discover.ActionsDiscovered{
+			Packages: []discover.ActionsPackage{
+				{Dir: "lib", Files: []string{"helpers.go"}},
+			},
+		},
+		[]*parser.Function{
+			{Name: "Build"},
+		},
+	)
+	require.NoError(t, err)
+
+	mainFile, err := os.ReadFile(path.Join(shuttlelocaldir, "tmp", "main.go"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(mainFile), "actions_lib")
+}
+
+func TestGenerateMainFileAliasCollision(t *testing.T) {
+	t.Parallel()
+
+	shuttlelocaldir := t.TempDir()
+	require.NoError(t, os.MkdirAll(path.Join(shuttlelocaldir, "tmp"), 0o755))
+	require.NoError(t, os.WriteFile(path.Join(shuttlelocaldir, "tmp", "go.mod"), []byte("module actions\n"), 0o644))
+
+	err := GenerateMainFile(
+		context.Background(),
+		shuttlelocaldir,
+		&discover.ActionsDiscovered{
+			Packages: []discover.ActionsPackage{
+				{Dir: "db-x", Files: []string{"migrate.go"}},
+				{Dir: "db_x", Files: []string{"migrate.go"}},
+			},
+		},
+		[]*parser.Function{
+			{Name: "Migrate", Namespace: "db-x"},
+			{Name: "Migrate", Namespace: "db_x"},
+		},
+	)
+
+	assert.EqualError(t, err, "actions packages db-x and db_x are both imported as actions_db_x, rename one of them")
+}
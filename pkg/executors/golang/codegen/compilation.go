@@ -2,6 +2,7 @@ package codegen
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"os"
 	"os/exec"
@@ -23,6 +24,30 @@ func CompileBinary(ctx context.Context, ui *ui.UI, shuttlelocaldir string) (stri
 		log.Printf("compile-binary output: %s", string(output))
 		return "", err
 	}
+	if len(output) > 0 {
+		ui.Verboseln("go build: %s", string(output))
+	}
 
 	return path.Join(shuttlelocaldir, "tmp", "actions"), nil
 }
+
+// CrossCompileBinary builds the binary for goos and goarch into output. Binaries are built statically and without
+// local paths, as they are shipped to other machines
+func CrossCompileBinary(ctx context.Context, ui *ui.UI, shuttlelocaldir, goos, goarch, binaryPath string) error {
+	cmd := exec.CommandContext(ctx, "go", "build", "-trimpath", "-o", binaryPath)
+	cmd.Env = os.Environ()
+	// We need to set workspaces off, as we don't want users to have to add the golang modules to their go.work
+	cmd.Env = append(cmd.Env, "GOWORK=off", "CGO_ENABLED=0", "GOOS="+goos, "GOARCH="+goarch)
+
+	cmd.Dir = path.Join(shuttlelocaldir, "tmp")
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, string(output))
+	}
+	if len(output) > 0 {
+		ui.Verboseln("go build: %s", string(output))
+	}
+
+	return nil
+}
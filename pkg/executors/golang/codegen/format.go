@@ -17,6 +17,9 @@ func Format(ctx context.Context, ui *ui.UI, shuttlelocaldir string) error {
 		ui.Errorln("go fmt: %s, error: %v", string(output), err)
 		return err
 	}
+	if len(output) > 0 {
+		ui.Verboseln("go fmt: %s", string(output))
+	}
 
 	return nil
 }
@@ -4,6 +4,8 @@ import (
 	"context"
 	"io/fs"
 	"os"
+	"path"
+	"strings"
 )
 
 type writeFileFunc = func(name string, contents []byte, permissions fs.FileMode) error
@@ -24,6 +26,32 @@ func NewPatcher() *Patcher {
 	}
 }
 
+// Modules returns the local modules the actions module in actionsDir is patched to use, keyed by module name with
+// paths relative to rootDir
+func (p *Patcher) Modules(ctx context.Context, rootDir string, actionsDir string) (map[string]string, error) {
+	packages, err := p.patchFinder.findPackages(ctx, rootDir)
+	if err != nil {
+		return nil, err
+	}
+
+	content, err := os.ReadFile(path.Join(actionsDir, "go.mod"))
+	if err != nil {
+		return nil, err
+	}
+	actionsModFile := &actionsModFile{
+		content: strings.Split(string(content), "\n"),
+	}
+
+	modules := make(map[string]string, len(packages))
+	for name, modulePath := range packages {
+		if actionsModFile.containsModule(name) {
+			modules[name] = modulePath
+		}
+	}
+
+	return modules, nil
+}
+
 func (p *Patcher) Patch(ctx context.Context, rootDir string, shuttleLocalDir string) error {
 	packages, err := p.patchFinder.findPackages(ctx, rootDir)
 	if err != nil {
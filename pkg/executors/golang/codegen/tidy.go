@@ -17,6 +17,9 @@ func ModTidy(ctx context.Context, ui *ui.UI, shuttlelocaldir string) error {
 		ui.Errorln("mod tidy: %s, error: %v", string(output), err)
 		return err
 	}
+	if len(output) > 0 {
+		ui.Verboseln("go mod tidy: %s", string(output))
+	}
 
 	return nil
 }
@@ -2,15 +2,22 @@ package compile
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"os/exec"
 	"path"
+	"path/filepath"
+	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 
 	"dagger.io/dagger"
+	cp "github.com/otiai10/copy"
+
+	"github.com/lunarway/shuttle/pkg/executors/golang/cache"
 	"github.com/lunarway/shuttle/pkg/executors/golang/codegen"
 	"github.com/lunarway/shuttle/pkg/executors/golang/compile/matcher"
 	"github.com/lunarway/shuttle/pkg/executors/golang/discover"
@@ -23,8 +30,24 @@ import (
 
 const (
 	alwaysBuild = false
+	// cachedBinaries is how many binaries are kept in .shuttle/actions/binaries
+	cachedBinaries = 5
 )
 
+// Option configures Compile
+type Option func(*options)
+
+type options struct {
+	force bool
+}
+
+// WithForce compiles the binaries even if a cached binary matches
+func WithForce() Option {
+	return func(o *options) {
+		o.force = true
+	}
+}
+
 type Binary struct {
 	Path string
 }
@@ -45,14 +68,19 @@ type Binaries struct {
 // 2.2. Generate main file
 //
 // 3. Move binary to .shuttle/actions/binary-<hash>
-func Compile(ctx context.Context, ui *ui.UI, discovered *discover.Discovered) (*Binaries, error) {
+func Compile(ctx context.Context, ui *ui.UI, discovered *discover.Discovered, opts ...Option) (*Binaries, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	egrp, ctx := errgroup.WithContext(ctx)
 	binaries := &Binaries{}
 	if discovered.Local != nil {
 		egrp.Go(func() error {
 			ui.Verboseln("compiling golang actions binary for: %s", discovered.Local.DirPath)
 
-			path, err := compile(ctx, ui, discovered.Local)
+			path, err := compile(ctx, ui, discovered.Local, o)
 			if err != nil {
 				return err
 			}
@@ -65,7 +93,7 @@ func Compile(ctx context.Context, ui *ui.UI, discovered *discover.Discovered) (*
 		egrp.Go(func() error {
 			ui.Verboseln("compiling golang actions binary for: %s", discovered.Plan.DirPath)
 
-			path, err := compile(ctx, ui, discovered.Plan)
+			path, err := compile(ctx, ui, discovered.Plan, o)
 			if err != nil {
 				return err
 			}
@@ -82,8 +110,20 @@ func Compile(ctx context.Context, ui *ui.UI, discovered *discover.Discovered) (*
 	return binaries, nil
 }
 
-func compile(ctx context.Context, ui *ui.UI, actions *discover.ActionsDiscovered) (string, error) {
-	hash, err := matcher.GetHash(ctx, actions)
+func compile(ctx context.Context, ui *ui.UI, actions *discover.ActionsDiscovered, o options) (string, error) {
+	if !o.force {
+		prebuiltPath, ok, err := prebuiltBinary(ctx, actions)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			ui.Verboseln("using prebuilt actions binary: %s", prebuiltPath)
+			return prebuiltPath, nil
+		}
+	}
+
+	shuttlelocaldir := path.Join(actions.ParentDir, ".shuttle/actions")
+	hash, err := matcher.GetHash(ctx, actions, toolchain(ctx, shuttlelocaldir))
 	if err != nil {
 		return "", err
 	}
@@ -93,36 +133,35 @@ func compile(ctx context.Context, ui *ui.UI, actions *discover.ActionsDiscovered
 		return "", err
 	}
 
-	if ok && !alwaysBuild {
+	if ok && !alwaysBuild && !o.force {
 		ui.Verboseln("file already matches continueing")
 		// The binary is the same so we short circuit
 		return binaryPath, nil
 	}
 
-	shuttlelocaldir := path.Join(actions.ParentDir, ".shuttle/actions")
-
-	if err = shuttlefolder.GenerateTmpDir(ctx, shuttlelocaldir); err != nil {
-		return "", err
-	}
-	if err = shuttlefolder.CopyFiles(ctx, shuttlelocaldir, actions); err != nil {
-		return "", err
-	}
+	finalBinaryPath := shuttlefolder.CalculateBinaryPath(shuttlelocaldir, hash)
 
-	contents, err := parser.GenerateAst(ctx, shuttlelocaldir, actions)
+	globalCache, err := cache.Default()
 	if err != nil {
-		return "", err
+		ui.Verboseln("golang actions cache is disabled: %v", err)
+	}
+	if globalCache != nil && !o.force {
+		ok, err := restoreFromCache(globalCache, hash, finalBinaryPath)
+		if err != nil {
+			ui.Verboseln("failed to restore actions binary from cache: %v", err)
+		}
+		if ok {
+			ui.Verboseln("actions binary restored from cache: %s", globalCache.Dir())
+			return finalBinaryPath, nil
+		}
 	}
 
-	if err = codegen.GenerateMainFile(ctx, shuttlelocaldir, actions, contents); err != nil {
+	if err := generateWorkspace(ctx, actions, shuttlelocaldir); err != nil {
 		return "", err
 	}
 
 	var binarypath string
 
-	if err := codegen.NewPatcher().Patch(ctx, actions.ParentDir, shuttlelocaldir); err != nil {
-		return "", fmt.Errorf("failed to patch generated go.mod: %w", err)
-	}
-
 	if goInstalled() {
 		if err = codegen.ModTidy(ctx, ui, shuttlelocaldir); err != nil {
 			return "", fmt.Errorf("go mod tidy failed: %w", err)
@@ -145,14 +184,228 @@ func compile(ctx context.Context, ui *ui.UI, actions *discover.ActionsDiscovered
 		return "", golangerrors.ErrGolangActionNoBuilder
 	}
 
-	finalBinaryPath := shuttlefolder.CalculateBinaryPath(shuttlelocaldir, hash)
 	if err := shuttlefolder.Move(binarypath, finalBinaryPath); err != nil {
 		return "", fmt.Errorf("failed to remove actions binary to final destination: %w", err)
 	}
 
+	if err := shuttlefolder.PruneBinaries(shuttlelocaldir, cachedBinaries); err != nil {
+		ui.Verboseln("failed to prune cached actions binaries: %v", err)
+	}
+
+	if globalCache != nil {
+		if err := globalCache.Store(hash, finalBinaryPath); err != nil {
+			ui.Verboseln("failed to store actions binary in cache: %v", err)
+		}
+	}
+
 	return finalBinaryPath, nil
 }
 
+// FindPrebuilt looks for a binary in actions/dist built from the current sources for this platform, see Export
+func FindPrebuilt(ctx context.Context, actions *discover.ActionsDiscovered) (string, bool, error) {
+	if _, err := os.Stat(path.Join(actions.DirPath, shuttlefolder.DistDir)); err != nil {
+		// the sources are only hashed if the actions ship prebuilt binaries
+		return "", false, nil
+	}
+
+	hash, err := matcher.GetSourceHash(ctx, actions)
+	if err != nil {
+		return "", false, err
+	}
+
+	prebuiltPath := shuttlefolder.PrebuiltBinaryPath(actions.DirPath, hash, runtime.GOOS, runtime.GOARCH)
+	if _, err := os.Stat(prebuiltPath); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+
+	return prebuiltPath, true, nil
+}
+
+// prebuiltBinary returns the path of the prebuilt binary matching the sources, if any
+func prebuiltBinary(ctx context.Context, actions *discover.ActionsDiscovered) (string, bool, error) {
+	prebuiltPath, ok, err := FindPrebuilt(ctx, actions)
+	if err != nil || !ok {
+		return "", false, err
+	}
+
+	info, err := os.Stat(prebuiltPath)
+	if err != nil {
+		return "", false, err
+	}
+	if info.Mode()&0o111 != 0 {
+		return prebuiltPath, true, nil
+	}
+
+	// some checkouts lose the executable bit, so the binary is copied into the project instead
+	binaryPath := path.Join(actions.ParentDir, ".shuttle/actions", shuttlefolder.TaskBinaryDir, path.Base(prebuiltPath))
+	if _, err := os.Stat(binaryPath); err == nil {
+		return binaryPath, true, nil
+	}
+	if err := cp.Copy(prebuiltPath, binaryPath, cp.Options{PermissionControl: cp.AddPermission(0o755)}); err != nil {
+		return "", false, fmt.Errorf("failed to copy prebuilt actions binary: %w", err)
+	}
+
+	return binaryPath, true, nil
+}
+
+// Platform is a target of prebuilt binaries
+type Platform struct {
+	OS   string
+	Arch string
+}
+
+func (p Platform) String() string {
+	return p.OS + "/" + p.Arch
+}
+
+// ParsePlatform parses a platform in the format of go tool dist list, i.e. linux/amd64
+func ParsePlatform(raw string) (Platform, error) {
+	goos, goarch, ok := strings.Cut(raw, "/")
+	if !ok || goos == "" || goarch == "" {
+		return Platform{}, fmt.Errorf("invalid platform '%s', expected os/arch, i.e. linux/amd64", raw)
+	}
+
+	return Platform{OS: goos, Arch: goarch}, nil
+}
+
+// DefaultPlatforms are the platforms shuttle is released for
+var DefaultPlatforms = []Platform{
+	{OS: "linux", Arch: "amd64"},
+	{OS: "linux", Arch: "arm64"},
+	{OS: "darwin", Arch: "amd64"},
+	{OS: "darwin", Arch: "arm64"},
+}
+
+// Export builds prebuilt binaries of actions for platforms into actions/dist, which are used instead of compiling the
+// actions when the sources match. Prebuilt binaries of previous sources are removed. It returns the written binaries
+func Export(
+	ctx context.Context,
+	ui *ui.UI,
+	actions *discover.ActionsDiscovered,
+	platforms []Platform,
+) ([]string, error) {
+	if !goInstalled() {
+		return nil, errors.New("go is required to export golang actions")
+	}
+
+	hash, err := matcher.GetSourceHash(ctx, actions)
+	if err != nil {
+		return nil, err
+	}
+
+	shuttlelocaldir := path.Join(actions.ParentDir, ".shuttle/actions")
+	if err := generateWorkspace(ctx, actions, shuttlelocaldir); err != nil {
+		return nil, err
+	}
+	if err := codegen.ModTidy(ctx, ui, shuttlelocaldir); err != nil {
+		return nil, fmt.Errorf("go mod tidy failed: %w", err)
+	}
+
+	distDir := path.Join(actions.DirPath, shuttlefolder.DistDir)
+	if err := os.MkdirAll(distDir, 0o755); err != nil {
+		return nil, err
+	}
+
+	exported := make([]string, 0, len(platforms))
+	for _, platform := range platforms {
+		binaryPath := shuttlefolder.PrebuiltBinaryPath(actions.DirPath, hash, platform.OS, platform.Arch)
+		ui.Verboseln("exporting golang actions for %s", platform)
+		if err := codegen.CrossCompileBinary(ctx, ui, shuttlelocaldir, platform.OS, platform.Arch, binaryPath); err != nil {
+			return exported, fmt.Errorf("go build failed for %s: %w", platform, err)
+		}
+		exported = append(exported, binaryPath)
+	}
+
+	entries, err := os.ReadDir(distDir)
+	if err != nil {
+		return exported, err
+	}
+	for _, entry := range entries {
+		entryPath := path.Join(distDir, entry.Name())
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), shuttlefolder.TaskBinaryPrefix+"-") ||
+			slices.Contains(exported, entryPath) {
+			continue
+		}
+
+		ui.Verboseln("removing outdated prebuilt binary: %s", entryPath)
+		if err := os.Remove(entryPath); err != nil {
+			return exported, err
+		}
+	}
+
+	return exported, nil
+}
+
+// PrepareWorkspace generates the module the actions binary is built from in .shuttle/actions/tmp, including the tests
+// of the actions, and returns its directory. It requires go, as the workspace is used by the go tool directly
+func PrepareWorkspace(ctx context.Context, ui *ui.UI, actions *discover.ActionsDiscovered) (string, error) {
+	if !goInstalled() {
+		return "", golangerrors.ErrGolangActionNoBuilder
+	}
+
+	shuttlelocaldir := path.Join(actions.ParentDir, ".shuttle/actions")
+	if err := generateWorkspace(ctx, actions, shuttlelocaldir); err != nil {
+		return "", err
+	}
+
+	if err := codegen.ModTidy(ctx, ui, shuttlelocaldir); err != nil {
+		return "", fmt.Errorf("go mod tidy failed: %w", err)
+	}
+
+	return path.Join(shuttlelocaldir, "tmp"), nil
+}
+
+// generateWorkspace copies the actions module into the tmp dir of shuttlelocaldir, generates the main file and patches
+// go.mod with the local modules of the project
+func generateWorkspace(ctx context.Context, actions *discover.ActionsDiscovered, shuttlelocaldir string) error {
+	if err := shuttlefolder.GenerateTmpDir(ctx, shuttlelocaldir); err != nil {
+		return err
+	}
+	if err := shuttlefolder.CopyFiles(ctx, shuttlelocaldir, actions); err != nil {
+		return err
+	}
+
+	contents, err := parser.GenerateAst(ctx, shuttlelocaldir, actions)
+	if err != nil {
+		return err
+	}
+
+	if err := codegen.GenerateMainFile(ctx, shuttlelocaldir, actions, contents); err != nil {
+		return err
+	}
+
+	if err := codegen.NewPatcher().Patch(ctx, actions.ParentDir, shuttlelocaldir); err != nil {
+		return fmt.Errorf("failed to patch generated go.mod: %w", err)
+	}
+
+	return nil
+}
+
+// restoreFromCache links or copies the binary built from hash out of the user level cache into the project
+func restoreFromCache(globalCache *cache.Cache, hash string, binaryPath string) (bool, error) {
+	cachedPath, ok, err := globalCache.Lookup(hash)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	if err := os.MkdirAll(path.Dir(binaryPath), 0o755); err != nil {
+		return false, err
+	}
+	if err := os.Link(cachedPath, binaryPath); err == nil {
+		return true, nil
+	}
+
+	// hard links fail across file systems
+	if err := cp.Copy(cachedPath, binaryPath, cp.Options{PermissionControl: cp.AddPermission(0o755)}); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
 func compileWithDagger(ctx context.Context, ui *ui.UI, shuttlelocaldir string) (string, error) {
 	client, err := dagger.Connect(ctx, dagger.WithLogOutput(os.Stderr))
 	if err != nil {
@@ -212,6 +465,94 @@ func compileWithDagger(ctx context.Context, ui *ui.UI, shuttlelocaldir string) (
 	return path.Join(shuttlelocaldir, "tmp", "actions"), nil
 }
 
+// Builder returns the builder used to compile actions binaries, either go or dagger. ok is false if neither is
+// available
+func Builder() (builder string, ok bool) {
+	if goInstalled() {
+		return "go", true
+	}
+	if goDaggerFallback() {
+		return "dagger " + getGolangImage(), true
+	}
+
+	return "", false
+}
+
+// toolchain describes the go toolchain the binary is compiled with, as part of the cache key. Running go env takes
+// longer than the rest of a run with a cached binary, so its output is kept in shuttlelocaldir for as long as the go
+// binary and the environment selecting the toolchain are unchanged
+func toolchain(ctx context.Context, shuttlelocaldir string) string {
+	if goInstalled() {
+		key := goEnvKey()
+		envFile := path.Join(shuttlelocaldir, goEnvFile)
+		if content, err := os.ReadFile(envFile); err == nil && key != "" {
+			cachedKey, output, ok := strings.Cut(string(content), "\n\n")
+			if ok && cachedKey == key {
+				return "go\n" + output
+			}
+		}
+
+		output, err := exec.CommandContext(ctx, "go", "env", "GOVERSION", "GOOS", "GOARCH").Output()
+		if err != nil {
+			return "go"
+		}
+		if key != "" && os.MkdirAll(shuttlelocaldir, 0o755) == nil {
+			// the output is only cached to save time, so failing to write it is ignored
+			_ = os.WriteFile(envFile, []byte(key+"\n\n"+string(output)), 0o644)
+		}
+		return "go\n" + string(output)
+	}
+	if goDaggerFallback() {
+		return "dagger\n" + getGolangImage()
+	}
+
+	return ""
+}
+
+// goEnvFile caches the output of go env in .shuttle/actions, see toolchain
+const goEnvFile = "go-env"
+
+// goEnvKey identifies the go binary on PATH and the environment go env depends on. It is empty if the go binary can't
+// be identified
+func goEnvKey() string {
+	goPath, err := exec.LookPath("go")
+	if err != nil {
+		return ""
+	}
+	goInfo, err := os.Stat(goPath)
+	if err != nil {
+		return ""
+	}
+	// the toolchain may be selected by the go.mod of the working directory
+	wd, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+
+	key := []string{
+		goPath,
+		strconv.FormatInt(goInfo.ModTime().UnixNano(), 10),
+		strconv.FormatInt(goInfo.Size(), 10),
+		wd,
+	}
+	for _, env := range []string{"GOOS", "GOARCH", "GOTOOLCHAIN", "GOROOT", "GOENV"} {
+		key = append(key, env+"="+os.Getenv(env))
+	}
+
+	// go env -w stores its settings in the go env file
+	goEnv := os.Getenv("GOENV")
+	if goEnv == "" {
+		if configDir, err := os.UserConfigDir(); err == nil {
+			goEnv = filepath.Join(configDir, "go", "env")
+		}
+	}
+	if info, err := os.Stat(goEnv); err == nil {
+		key = append(key, strconv.FormatInt(info.ModTime().UnixNano(), 10))
+	}
+
+	return strings.Join(key, "\n")
+}
+
 func goInstalled() bool {
 	gopath, err := exec.LookPath("go")
 	if err != nil {
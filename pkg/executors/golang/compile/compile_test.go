@@ -3,16 +3,23 @@ package compile_test
 import (
 	"context"
 	"os"
+	"path"
+	"runtime"
 	"testing"
 
 	"github.com/lunarway/shuttle/pkg/config"
 	"github.com/lunarway/shuttle/pkg/executors/golang/compile"
+	"github.com/lunarway/shuttle/pkg/executors/golang/compile/matcher"
 	"github.com/lunarway/shuttle/pkg/executors/golang/discover"
+	"github.com/lunarway/shuttle/pkg/executors/golang/shuttlefolder"
 	"github.com/lunarway/shuttle/pkg/ui"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCompile(t *testing.T) {
+	t.Setenv("SHUTTLE_GOLANG_ACTIONS_CACHE_DIR", t.TempDir())
+
 	ctx := context.Background()
 	discovered, err := discover.Discover(
 		ctx,
@@ -23,8 +30,68 @@ func TestCompile(t *testing.T) {
 
 	uiout := ui.Create(os.Stdout, os.Stderr)
 
-	path, err := compile.Compile(ctx, uiout, discovered)
+	binaries, err := compile.Compile(ctx, uiout, discovered)
 	assert.NoError(t, err)
 
-	assert.Contains(t, path.Local.Path, "testdata/simple/.shuttle/actions/binaries/actions-")
+	assert.Contains(t, binaries.Local.Path, "testdata/simple/.shuttle/actions/binaries/actions-")
+}
+
+func TestCompilePrebuilt(t *testing.T) {
+	t.Setenv("SHUTTLE_GOLANG_ACTIONS_CACHE_DIR", t.TempDir())
+	// prebuilt binaries are used without a go toolchain
+	t.Setenv("PATH", "")
+
+	ctx := context.Background()
+	projectDir := t.TempDir()
+	writeFile(t, path.Join(projectDir, "shuttle.yaml"), "plan: false\n")
+	writeFile(t, path.Join(projectDir, "actions/go.mod"), "module actions\n")
+	writeFile(t, path.Join(projectDir, "actions/build.go"), "package main\n\nfunc Build() {}\n")
+
+	discovered, err := discover.Discover(
+		ctx,
+		path.Join(projectDir, "shuttle.yaml"),
+		&config.ShuttleProjectContext{},
+	)
+	require.NoError(t, err)
+
+	hash, err := matcher.GetSourceHash(ctx, discovered.Local)
+	require.NoError(t, err)
+	prebuiltPath := shuttlefolder.PrebuiltBinaryPath(discovered.Local.DirPath, hash, runtime.GOOS, runtime.GOARCH)
+	require.NoError(t, os.MkdirAll(path.Dir(prebuiltPath), 0o755))
+	require.NoError(t, os.WriteFile(prebuiltPath, []byte("#!/bin/sh\n"), 0o755))
+	// binaries of other sources are ignored
+	otherPath := shuttlefolder.PrebuiltBinaryPath(discovered.Local.DirPath, "h1:Dh6GC1ZwdmVsXqUBPsqCWS+XZUgjTqIiYUJcCPrGOD0=", runtime.GOOS, runtime.GOARCH)
+	require.NoError(t, os.WriteFile(otherPath, []byte("#!/bin/sh\n"), 0o755))
+
+	uiout := ui.Create(os.Stdout, os.Stderr)
+
+	binaries, err := compile.Compile(ctx, uiout, discovered)
+	require.NoError(t, err)
+	assert.Equal(t, prebuiltPath, binaries.Local.Path)
+
+	// changed sources don't match the prebuilt binary, and require go to compile
+	writeFile(t, path.Join(projectDir, "actions/build.go"), "package main\n\nfunc Build() {}\n\nfunc Deploy() {}\n")
+
+	_, found, err := compile.FindPrebuilt(ctx, discovered.Local)
+	require.NoError(t, err)
+	assert.False(t, found)
+}
+
+func TestParsePlatform(t *testing.T) {
+	platform, err := compile.ParsePlatform("darwin/arm64")
+	require.NoError(t, err)
+	assert.Equal(t, compile.Platform{OS: "darwin", Arch: "arm64"}, platform)
+	assert.Equal(t, "darwin/arm64", platform.String())
+
+	for _, raw := range []string{"", "linux", "linux/", "/amd64"} {
+		_, err := compile.ParsePlatform(raw)
+		assert.Error(t, err, raw)
+	}
+}
+
+func writeFile(t *testing.T, file string, content string) {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(path.Dir(file), 0o755))
+	require.NoError(t, os.WriteFile(file, []byte(content), 0o644))
 }
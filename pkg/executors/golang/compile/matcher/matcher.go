@@ -3,63 +3,141 @@ package matcher
 import (
 	"bytes"
 	"context"
-	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"time"
 
+	"github.com/lunarway/shuttle/pkg/executors/golang/codegen"
 	"github.com/lunarway/shuttle/pkg/executors/golang/discover"
+	"github.com/lunarway/shuttle/pkg/executors/golang/shuttlefolder"
 	"github.com/lunarway/shuttle/pkg/ui"
 	"golang.org/x/exp/slices"
+	"golang.org/x/mod/modfile"
 	"golang.org/x/mod/sumdb/dirhash"
 )
 
+// BinaryMatches looks for a cached binary built from hash. Several binaries are kept, so switching back and forth
+// between branches doesn't require a rebuild every time
 func BinaryMatches(
 	ctx context.Context,
 	ui *ui.UI,
 	hash string,
 	actions *discover.ActionsDiscovered,
 ) (string, bool, error) {
-	shuttlebindir := path.Join(actions.ParentDir, ".shuttle/actions/binaries")
+	shuttlelocaldir := path.Join(actions.ParentDir, ".shuttle/actions")
+	binaryPath := shuttlefolder.CalculateBinaryPath(shuttlelocaldir, hash)
 
-	if _, err := os.Stat(shuttlebindir); errors.Is(err, os.ErrNotExist) {
-		ui.Verboseln("package doesn't exist continueing")
-		return "", false, nil
+	if _, err := os.Stat(binaryPath); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			ui.Verboseln("binary does not exist, rebuilding... (expected=%s)", binaryPath)
+			return "", false, nil
+		}
+		return "", false, err
 	}
 
-	entries, err := os.ReadDir(shuttlebindir)
-	if err != nil {
+	// the modification time is used to evict the least recently used binaries
+	now := time.Now()
+	if err := os.Chtimes(binaryPath, now, now); err != nil {
 		return "", false, err
 	}
 
-	if len(entries) == 0 {
-		return "", false, err
+	return binaryPath, true, nil
+}
+
+// GetHash hashes everything the actions binary is built from: every go file, go.mod and go.sum of the actions module,
+// the local modules it uses through replace directives or codegen.Patcher, the go toolchain and the version of shuttle
+// generating the main file.
+//
+// toolchain describes the go toolchain used to compile the binary.
+//
+// The modification times of the files are kept in .shuttle/actions, so the files are only read again once one of them
+// changed
+func GetHash(ctx context.Context, actions *discover.ActionsDiscovered, toolchain string) (string, error) {
+	// virtual entries aren't files, and are prefixed with a colon to avoid clashing with file entries
+	virtual := map[string]string{
+		":toolchain": toolchain,
+		":shuttle":   shuttleVersion(),
 	}
 
-	// We only expect a single binary in the folder, so we just take the first entry if it exists
-	binary := entries[0]
+	stampPath := path.Join(actions.ParentDir, ".shuttle/actions", stampFile)
+	if hash, ok := readStamp(stampPath, virtual); ok {
+		return hash, nil
+	}
 
-	expectedPath := fmt.Sprintf("actions-%s", hex.EncodeToString([]byte(hash)[:16]))
-	actualName := binary.Name()
-	if actualName == expectedPath {
-		return path.Join(shuttlebindir, binary.Name()), true, nil
-	} else {
-		ui.Verboseln("binary does not match, rebuilding... (actual=%s, expected=%s)", actualName, expectedPath)
-		return "", false, nil
+	hash, in, err := hash(ctx, actions, virtual)
+	if err != nil {
+		return "", err
 	}
+	// the stamp only saves work, so the hash is used even if it can't be written
+	_ = writeStamp(stampPath, virtual, hash, in)
+
+	return hash, nil
+}
+
+// GetSourceHash hashes the sources of the actions binary like GetHash, but not the toolchain and shuttle building it.
+// It identifies prebuilt binaries, which are used on machines without a go toolchain
+func GetSourceHash(ctx context.Context, actions *discover.ActionsDiscovered) (string, error) {
+	hash, _, err := hash(ctx, actions, nil)
+	return hash, err
 }
 
-func GetHash(ctx context.Context, actions *discover.ActionsDiscovered) (string, error) {
-	entries := make([]string, len(actions.Files))
+// hash returns the hash of the actions along with the files and directories it is computed from
+func hash(ctx context.Context, actions *discover.ActionsDiscovered, virtual map[string]string) (string, inputs, error) {
+	// files maps the name of each hash entry to the file it is read from
+	files := make(map[string]string)
+	in := make(inputs)
+
+	if err := addModuleFiles(files, in, "actions", actions.DirPath); err != nil {
+		return "", nil, err
+	}
+
+	replaced, err := replacedModules(actions.DirPath)
+	if err != nil {
+		return "", nil, err
+	}
+	for name, dir := range replaced {
+		if err := addModuleFiles(files, in, path.Join("replace", name), dir); err != nil {
+			return "", nil, err
+		}
+	}
+
+	if _, err := os.Stat(path.Join(actions.DirPath, "go.mod")); err == nil {
+		// the patched modules are found through the go.work or go.mod of the project
+		in.add(path.Join(actions.ParentDir, "go.work"))
+		in.add(path.Join(actions.ParentDir, "go.mod"))
 
-	for i, task := range actions.Files {
-		entries[i] = path.Join(actions.DirPath, task)
+		patched, err := codegen.NewPatcher().Modules(ctx, actions.ParentDir, actions.DirPath)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to find patched modules: %w", err)
+		}
+		for name, dir := range patched {
+			if err := addModuleFiles(files, in, path.Join("patch", name), path.Join(actions.ParentDir, dir)); err != nil {
+				return "", nil, err
+			}
+		}
+	}
+
+	entries := make([]string, 0, len(files)+len(virtual))
+	for name := range files {
+		entries = append(entries, name)
+	}
+	for name := range virtual {
+		entries = append(entries, name)
 	}
 
 	open := func(name string) (io.ReadCloser, error) {
-		b, err := os.ReadFile(name)
+		if content, ok := virtual[name]; ok {
+			return io.NopCloser(strings.NewReader(content)), nil
+		}
+
+		b, err := os.ReadFile(files[name])
 		if err != nil {
 			return nil, err
 		}
@@ -70,8 +148,107 @@ func GetHash(ctx context.Context, actions *discover.ActionsDiscovered) (string,
 	slices.Sort(entries)
 	hash, err := dirhash.Hash1(entries, open)
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
-	return hash, nil
+	return hash, in, nil
+}
+
+// addModuleFiles adds the files of the module in dir affecting a build. Nested modules and directories ignored by
+// the go tool are skipped. The walked directories and added files are recorded in in
+func addModuleFiles(files map[string]string, in inputs, prefix string, dir string) error {
+	return filepath.WalkDir(dir, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() {
+			in.add(file)
+			if file == dir {
+				return nil
+			}
+
+			name := entry.Name()
+			if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") || name == "testdata" {
+				return filepath.SkipDir
+			}
+			if _, err := os.Stat(filepath.Join(file, "go.mod")); err == nil {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		name := entry.Name()
+		if !strings.HasSuffix(name, ".go") && name != "go.mod" && name != "go.sum" {
+			return nil
+		}
+		// tests aren't part of the binary
+		if strings.HasSuffix(name, "_test.go") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, file)
+		if err != nil {
+			return err
+		}
+		files[path.Join(prefix, filepath.ToSlash(rel))] = file
+		in.add(file)
+
+		return nil
+	})
+}
+
+// replacedModules returns the local directories of the replace directives in the go.mod of the actions module
+func replacedModules(actionsDir string) (map[string]string, error) {
+	goModPath := path.Join(actionsDir, "go.mod")
+	content, err := os.ReadFile(goModPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	goMod, err := modfile.Parse(goModPath, content, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", goModPath, err)
+	}
+
+	modules := make(map[string]string)
+	for _, replace := range goMod.Replace {
+		if !modfile.IsDirectoryPath(replace.New.Path) {
+			continue
+		}
+
+		dir := replace.New.Path
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(actionsDir, dir)
+		}
+		// go refuses replacements without a go.mod, and codegen.Patcher may have to fix the path
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err != nil {
+			continue
+		}
+		modules[replace.Old.Path] = dir
+	}
+
+	return modules, nil
+}
+
+// shuttleVersion identifies the running shuttle, as it generates the main file of the actions binary
+func shuttleVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+
+	version := []string{info.Main.Path, info.Main.Version, info.Main.Sum}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision", "vcs.modified":
+			version = append(version, setting.Value)
+		}
+	}
+
+	return strings.Join(version, "\n")
 }
@@ -0,0 +1,217 @@
+package matcher_test
+
+import (
+	"context"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lunarway/shuttle/pkg/executors/golang/compile/matcher"
+	"github.com/lunarway/shuttle/pkg/executors/golang/discover"
+	"github.com/lunarway/shuttle/pkg/executors/golang/shuttlefolder"
+	"github.com/lunarway/shuttle/pkg/ui"
+)
+
+func writeFile(t *testing.T, file string, content string) {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(path.Dir(file), 0o755))
+	require.NoError(t, os.WriteFile(file, []byte(content), 0o644))
+}
+
+func TestGetHash(t *testing.T) {
+	ctx := context.Background()
+
+	root := t.TempDir()
+	writeFile(t, path.Join(root, "shared/go.mod"), "module shared\n")
+	writeFile(t, path.Join(root, "shared/shared.go"), "package shared\n")
+	writeFile(t, path.Join(root, "project/actions/go.mod"), "module actions\n\nreplace shared => ../../shared\n")
+	writeFile(t, path.Join(root, "project/actions/build.go"), "package main\n")
+	writeFile(t, path.Join(root, "project/actions/helpers/helpers.go"), "package helpers\n")
+
+	actions := &discover.ActionsDiscovered{
+		Files:     []string{"build.go"},
+		DirPath:   path.Join(root, "project/actions"),
+		ParentDir: path.Join(root, "project"),
+	}
+
+	hash := func(toolchain string) string {
+		t.Helper()
+
+		h, err := matcher.GetHash(ctx, actions, toolchain)
+		require.NoError(t, err)
+		return h
+	}
+
+	initial := hash("go1.26")
+	assert.Equal(t, initial, hash("go1.26"), "hash is stable")
+
+	tt := []struct {
+		name   string
+		change func()
+	}{
+		{
+			name:   "toolchain",
+			change: func() {},
+		},
+		{
+			name: "go.sum",
+			change: func() {
+				writeFile(t, path.Join(root, "project/actions/go.sum"), "shared v1.0.0 h1:abc\n")
+			},
+		},
+		{
+			name: "helper package",
+			change: func() {
+				writeFile(t, path.Join(root, "project/actions/helpers/helpers.go"), "package helpers\n\nfunc Help() {}\n")
+			},
+		},
+		{
+			name: "replaced module",
+			change: func() {
+				writeFile(t, path.Join(root, "shared/shared.go"), "package shared\n\nfunc Share() {}\n")
+			},
+		},
+	}
+	previous := map[string]bool{initial: true}
+	for i, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.change()
+
+			toolchain := "go1.26"
+			if i == 0 {
+				toolchain = "go1.27"
+			}
+			actual := hash(toolchain)
+
+			assert.False(t, previous[actual], "hash did not change")
+			previous[actual] = true
+		})
+	}
+
+	t.Run("ignored files", func(t *testing.T) {
+		before := hash("go1.26")
+
+		writeFile(t, path.Join(root, "project/actions/README.md"), "readme\n")
+		writeFile(t, path.Join(root, "project/actions/testdata/fixture.go"), "package fixture\n")
+
+		assert.Equal(t, before, hash("go1.26"))
+	})
+}
+
+func TestGetHashStamp(t *testing.T) {
+	ctx := context.Background()
+
+	root := t.TempDir()
+	writeFile(t, path.Join(root, "project/actions/go.mod"), "module actions\n")
+	writeFile(t, path.Join(root, "project/actions/build.go"), "package main\n")
+
+	actions := &discover.ActionsDiscovered{
+		Files:     []string{"build.go"},
+		DirPath:   path.Join(root, "project/actions"),
+		ParentDir: path.Join(root, "project"),
+	}
+
+	// files modified within the last seconds aren't trusted to keep their modification time
+	old := time.Now().Add(-time.Hour)
+	age := func(files ...string) {
+		t.Helper()
+		for _, file := range files {
+			require.NoError(t, os.Chtimes(path.Join(root, file), old, old))
+		}
+	}
+	age("project/actions/go.mod", "project/actions/build.go", "project/actions")
+
+	initial, err := matcher.GetHash(ctx, actions, "go1.26")
+	require.NoError(t, err)
+	assert.FileExists(t, path.Join(root, "project/.shuttle/actions/hash-stamp.json"))
+
+	// files aren't read while their modification times are unchanged
+	writeFile(t, path.Join(root, "project/actions/build.go"), "package xxxx\n")
+	age("project/actions/build.go")
+	cached, err := matcher.GetHash(ctx, actions, "go1.26")
+	require.NoError(t, err)
+	assert.Equal(t, initial, cached)
+
+	otherToolchain, err := matcher.GetHash(ctx, actions, "go1.27")
+	require.NoError(t, err)
+	assert.NotEqual(t, initial, otherToolchain)
+
+	writeFile(t, path.Join(root, "project/actions/build.go"), "package main\n\nfunc Build() {}\n")
+	changed, err := matcher.GetHash(ctx, actions, "go1.26")
+	require.NoError(t, err)
+	assert.NotEqual(t, initial, changed)
+
+	writeFile(t, path.Join(root, "project/actions/version.go"), "package main\n")
+	added, err := matcher.GetHash(ctx, actions, "go1.26")
+	require.NoError(t, err)
+	assert.NotEqual(t, changed, added)
+}
+
+func TestBinaryMatches(t *testing.T) {
+	ctx := context.Background()
+	parentDir := t.TempDir()
+	shuttlelocaldir := path.Join(parentDir, ".shuttle/actions")
+	actions := &discover.ActionsDiscovered{ParentDir: parentDir}
+	uiout := ui.Create(os.Stdout, os.Stderr)
+
+	const (
+		hash      = "h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="
+		otherHash = "h1:Dh6GC1ZwdmVsXqUBPsqCWS+XZUgjTqIiYUJcCPrGOD0="
+	)
+
+	_, ok, err := matcher.BinaryMatches(ctx, uiout, hash, actions)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	// several binaries are cached
+	writeFile(t, shuttlefolder.CalculateBinaryPath(shuttlelocaldir, otherHash), "")
+	writeFile(t, shuttlefolder.CalculateBinaryPath(shuttlelocaldir, hash), "")
+
+	binaryPath, ok, err := matcher.BinaryMatches(ctx, uiout, hash, actions)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, shuttlefolder.CalculateBinaryPath(shuttlelocaldir, hash), binaryPath)
+
+	binaryPath, ok, err = matcher.BinaryMatches(ctx, uiout, otherHash, actions)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, shuttlefolder.CalculateBinaryPath(shuttlelocaldir, otherHash), binaryPath)
+}
+
+func TestGetSourceHash(t *testing.T) {
+	ctx := context.Background()
+
+	root := t.TempDir()
+	writeFile(t, path.Join(root, "project/actions/go.mod"), "module actions\n")
+	writeFile(t, path.Join(root, "project/actions/build.go"), "package main\n")
+
+	actions := &discover.ActionsDiscovered{
+		Files:     []string{"build.go"},
+		DirPath:   path.Join(root, "project/actions"),
+		ParentDir: path.Join(root, "project"),
+	}
+
+	initial, err := matcher.GetSourceHash(ctx, actions)
+	require.NoError(t, err)
+
+	// prebuilt binaries don't depend on the toolchain of the machine running them
+	withToolchain, err := matcher.GetHash(ctx, actions, "go1.26")
+	require.NoError(t, err)
+	assert.NotEqual(t, initial, withToolchain)
+
+	// prebuilt binaries don't change the sources
+	writeFile(t, path.Join(root, "project/actions/dist/actions-abc-linux-amd64"), "binary")
+	unchanged, err := matcher.GetSourceHash(ctx, actions)
+	require.NoError(t, err)
+	assert.Equal(t, initial, unchanged)
+
+	writeFile(t, path.Join(root, "project/actions/build.go"), "package main\n\nfunc Build() {}\n")
+	changed, err := matcher.GetSourceHash(ctx, actions)
+	require.NoError(t, err)
+	assert.NotEqual(t, initial, changed)
+}
@@ -0,0 +1,112 @@
+package matcher
+
+import (
+	"encoding/json"
+	"maps"
+	"os"
+	"path"
+	"time"
+)
+
+// stampFile records the inputs of the last hash of the actions, so GetHash can skip reading every file while none of
+// them changed
+const stampFile = "hash-stamp.json"
+
+// modTimeCutoff leaves out stamps of files modified this recently, as they may change again without changing their
+// modification time on file systems with a coarse resolution
+const modTimeCutoff = 2 * time.Second
+
+type stamp struct {
+	Virtual map[string]string `json:"virtual"`
+	Inputs  inputs            `json:"inputs"`
+	Hash    string            `json:"hash"`
+}
+
+// inputs maps the files and directories a hash is computed from to their state. Directories are included, as their
+// modification time changes when files are added or removed
+type inputs map[string]inputState
+
+type inputState struct {
+	ModTime int64 `json:"modTime"`
+	Size    int64 `json:"size"`
+	Missing bool  `json:"missing,omitempty"`
+}
+
+func (in inputs) add(file string) {
+	in[file] = stateOf(file)
+}
+
+// changed reports whether any of the inputs changed since they were added
+func (in inputs) changed() bool {
+	for file, state := range in {
+		if stateOf(file) != state {
+			return true
+		}
+	}
+
+	return false
+}
+
+// recent reports whether any of the inputs was modified within modTimeCutoff of now
+func (in inputs) recent(now time.Time) bool {
+	cutoff := now.Add(-modTimeCutoff).UnixNano()
+	for _, state := range in {
+		if state.ModTime > cutoff {
+			return true
+		}
+	}
+
+	return false
+}
+
+func stateOf(file string) inputState {
+	info, err := os.Stat(file)
+	if err != nil {
+		return inputState{Missing: true}
+	}
+
+	return inputState{ModTime: info.ModTime().UnixNano(), Size: info.Size()}
+}
+
+// readStamp returns the hash of the stamp in file, if it was computed from the same virtual entries and none of its
+// inputs changed since
+func readStamp(file string, virtual map[string]string) (string, bool) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return "", false
+	}
+
+	var s stamp
+	if err := json.Unmarshal(content, &s); err != nil {
+		return "", false
+	}
+	if s.Hash == "" || len(s.Inputs) == 0 || !maps.Equal(s.Virtual, virtual) || s.Inputs.changed() {
+		return "", false
+	}
+
+	return s.Hash, true
+}
+
+// writeStamp records hash with the state of its inputs in file. Nothing is written if an input was modified too
+// recently to tell later changes apart
+func writeStamp(file string, virtual map[string]string, hash string, in inputs) error {
+	if in.recent(time.Now()) {
+		return nil
+	}
+
+	content, err := json.Marshal(stamp{Virtual: virtual, Inputs: in, Hash: hash})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(path.Dir(file), 0o755); err != nil {
+		return err
+	}
+	// other shuttle processes may read the stamp while it is written
+	tmpFile := file + ".tmp"
+	if err := os.WriteFile(tmpFile, content, 0o644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFile, file)
+}
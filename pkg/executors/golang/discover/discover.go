@@ -21,6 +21,15 @@ type ActionsDiscovered struct {
 	Files     []string
 	DirPath   string
 	ParentDir string
+	// Packages are the subdirectories of DirPath containing go files. Nil if there are none
+	Packages []ActionsPackage
+}
+
+// ActionsPackage is a subpackage of the actions dir, i.e. actions/db, whose actions are namespaced as db:migrate
+type ActionsPackage struct {
+	// Dir is the slash separated path relative to the actions dir, i.e. db or db/schema
+	Dir   string
+	Files []string
 }
 
 type Discovered struct {
@@ -75,33 +84,21 @@ func Discover(
 
 func discoverPlan(localdir string) (*ActionsDiscovered, error) {
 	localshuttledirentries := make([]string, 0)
+	var packages []ActionsPackage
 
 	actionspath := path.Join(localdir, actionsdir)
 	if fs, err := os.Stat(actionspath); err == nil {
 		// list all local files
 		if fs.IsDir() {
-			entries, err := os.ReadDir(actionspath)
+			files, dirs, err := readActionsDir(actionspath)
 			if err != nil {
 				return nil, err
 			}
+			localshuttledirentries = files
 
-			for _, entry := range entries {
-				// skip dirs
-				if entry.IsDir() {
-					continue
-				}
-
-				// skip non go files
-				if !strings.HasSuffix(entry.Name(), ".go") {
-					continue
-				}
-
-				// skip test files
-				if strings.HasSuffix(entry.Name(), "_test.go") {
-					continue
-				}
-
-				localshuttledirentries = append(localshuttledirentries, entry.Name())
+			packages, err = discoverPackages(actionspath, "", dirs)
+			if err != nil {
+				return nil, err
 			}
 		}
 
@@ -109,8 +106,77 @@ func discoverPlan(localdir string) (*ActionsDiscovered, error) {
 			DirPath:   actionspath,
 			Files:     localshuttledirentries,
 			ParentDir: localdir,
+			Packages:  packages,
 		}, nil
 
 	}
 	return nil, nil
 }
+
+// discoverPackages recursively finds the subpackages of the actions dir containing go files
+func discoverPackages(actionspath string, parent string, dirs []string) ([]ActionsPackage, error) {
+	var packages []ActionsPackage
+	for _, dir := range dirs {
+		dir = path.Join(parent, dir)
+
+		files, subdirs, err := readActionsDir(path.Join(actionspath, dir))
+		if err != nil {
+			return nil, err
+		}
+		if len(files) > 0 {
+			packages = append(packages, ActionsPackage{
+				Dir:   dir,
+				Files: files,
+			})
+		}
+
+		subpackages, err := discoverPackages(actionspath, dir, subdirs)
+		if err != nil {
+			return nil, err
+		}
+		packages = append(packages, subpackages...)
+	}
+
+	return packages, nil
+}
+
+// readActionsDir lists the go files which may contain actions, and the directories which may contain packages of actions
+func readActionsDir(dirpath string) (files []string, dirs []string, err error) {
+	entries, err := os.ReadDir(dirpath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	files = make([]string, 0)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			// skip dirs ignored by the go tool, and packages which can't be imported
+			if !ignoredDir(entry.Name()) {
+				dirs = append(dirs, entry.Name())
+			}
+			continue
+		}
+
+		// skip non go files
+		if !strings.HasSuffix(entry.Name(), ".go") {
+			continue
+		}
+
+		// skip test files
+		if strings.HasSuffix(entry.Name(), "_test.go") {
+			continue
+		}
+
+		files = append(files, entry.Name())
+	}
+
+	return files, dirs, nil
+}
+
+func ignoredDir(name string) bool {
+	return strings.HasPrefix(name, ".") ||
+		strings.HasPrefix(name, "_") ||
+		name == "testdata" ||
+		name == "internal" ||
+		name == "vendor"
+}
@@ -69,3 +69,32 @@ func TestDiscoverComplex(t *testing.T) {
 		},
 	}, *discovered)
 }
+
+func TestDiscoverPackages(t *testing.T) {
+	discovered, err := discover.Discover(
+		context.Background(),
+		"testdata/namespaced/shuttle.yaml",
+		&config.ShuttleProjectContext{},
+	)
+	assert.NoError(t, err)
+
+	assert.Equal(t, discover.Discovered{
+		Local: &discover.ActionsDiscovered{
+			Files: []string{
+				"build.go",
+			},
+			DirPath:   "testdata/namespaced/actions",
+			ParentDir: "testdata/namespaced",
+			Packages: []discover.ActionsPackage{
+				{
+					Dir:   "db",
+					Files: []string{"migrate.go"},
+				},
+				{
+					Dir:   "db/schema",
+					Files: []string{"apply.go"},
+				},
+			},
+		},
+	}, *discovered)
+}
@@ -1,6 +1,15 @@
 package executer
 
+import "github.com/lunarway/shuttle/pkg/executors/golang/protocol"
+
 type (
+	// Inquiry is the response of an actions binary to lsjson. Binaries built before the protocol was versioned only
+	// respond with their actions
+	Inquiry struct {
+		protocol.Handshake
+		*Actions
+	}
+
 	// Actions represents all the possible commands to be sent to the golang actions binaries.
 	// Such as `shuttle run daggerbuild --arg something`, in this daggerbuild would be the name, and arg being an Arg for said action
 	Actions struct {
@@ -8,11 +17,18 @@ type (
 	}
 
 	Action struct {
-		Args []ActionArg `json:"args"`
+		Description string      `json:"description,omitempty"`
+		Args        []ActionArg `json:"args"`
 	}
 
 	ActionArg struct {
-		Name string `json:"name"`
+		Name        string `json:"name"`
+		Description string `json:"description,omitempty"`
+		// Type is one of string, int, float, bool, duration or stringSlice. Empty is a string
+		Type string `json:"type,omitempty"`
+		// Optional is inverted, so actions binaries built before it existed still report required args
+		Optional bool   `json:"optional,omitempty"`
+		Default  string `json:"default,omitempty"`
 	}
 )
 
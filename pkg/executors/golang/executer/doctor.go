@@ -0,0 +1,192 @@
+package executer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/lunarway/shuttle/pkg/config"
+	"github.com/lunarway/shuttle/pkg/executors/golang/codegen"
+	"github.com/lunarway/shuttle/pkg/executors/golang/compile"
+	"github.com/lunarway/shuttle/pkg/executors/golang/discover"
+	"github.com/lunarway/shuttle/pkg/executors/golang/shuttlefolder"
+	"github.com/lunarway/shuttle/pkg/ui"
+)
+
+// Diagnostic statuses
+const (
+	DiagnosticOK      = "ok"
+	DiagnosticInfo    = "info"
+	DiagnosticWarning = "warning"
+	DiagnosticError   = "error"
+)
+
+// Diagnostic is the result of a single check of Diagnose
+type Diagnostic struct {
+	Check   string
+	Status  string
+	Message string
+}
+
+// Diagnose explains whether golang actions are available in the project, and why not if they aren't
+func Diagnose(
+	ctx context.Context,
+	ui *ui.UI,
+	shuttlepath string,
+	c *config.ShuttleProjectContext,
+) []Diagnostic {
+	diagnostics := make([]Diagnostic, 0)
+	add := func(check, status, format string, args ...any) {
+		diagnostics = append(diagnostics, Diagnostic{
+			Check:   check,
+			Status:  status,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	if isActionsEnabled() {
+		add("enabled", DiagnosticOK, "SHUTTLE_GOLANG_ACTIONS=%s", os.Getenv("SHUTTLE_GOLANG_ACTIONS"))
+	} else {
+		add(
+			"enabled",
+			DiagnosticWarning,
+			"golang actions are disabled, set SHUTTLE_GOLANG_ACTIONS=true to make them available in shuttle run",
+		)
+	}
+
+	builder, hasBuilder := compile.Builder()
+	switch {
+	case !hasBuilder:
+		add(
+			"builder",
+			DiagnosticError,
+			"go is not installed, and the dagger fallback is disabled. Install go or set SHUTTLE_GOLANG_ACTIONS_DAGGER_FALLBACK=true",
+		)
+	case builder == "go":
+		version, err := exec.CommandContext(ctx, "go", "version").Output()
+		if err != nil {
+			add("builder", DiagnosticError, "go is installed, but `go version` failed: %v", err)
+		} else {
+			add("builder", DiagnosticOK, "%s", strings.TrimSpace(string(version)))
+		}
+	default:
+		add("builder", DiagnosticOK, "go is not installed, using %s", builder)
+	}
+
+	disc, err := discover.Discover(ctx, shuttlepath, c)
+	if err != nil {
+		add("discover", DiagnosticError, "failed to discover actions: %v", err)
+		return diagnostics
+	}
+
+	sources := []struct {
+		name    string
+		actions *discover.ActionsDiscovered
+	}{
+		{name: "local", actions: disc.Local},
+		{name: "plan", actions: disc.Plan},
+	}
+	found := false
+	prebuilt := false
+	for _, source := range sources {
+		if source.actions == nil {
+			add(source.name, DiagnosticInfo, "no actions directory")
+			continue
+		}
+		found = true
+
+		add(
+			source.name,
+			DiagnosticOK,
+			"%s: %d files, %d packages",
+			source.actions.DirPath,
+			len(source.actions.Files),
+			len(source.actions.Packages),
+		)
+
+		if _, err := os.Stat(path.Join(source.actions.DirPath, "go.mod")); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				add(source.name+" go.mod", DiagnosticError, "%s/go.mod is missing, run shuttle actions init", source.actions.DirPath)
+			} else {
+				add(source.name+" go.mod", DiagnosticError, "%v", err)
+			}
+			continue
+		}
+
+		modules, err := codegen.NewPatcher().Modules(ctx, source.actions.ParentDir, source.actions.DirPath)
+		if err != nil {
+			add(source.name+" patcher", DiagnosticError, "failed to find modules to patch: %v", err)
+			continue
+		}
+		if len(modules) == 0 {
+			add(source.name+" patcher", DiagnosticOK, "no local modules are patched into go.mod")
+		} else {
+			replaces := make([]string, 0, len(modules))
+			for name, modulePath := range modules {
+				replaces = append(replaces, fmt.Sprintf("%s => %s", name, path.Join(source.actions.ParentDir, modulePath)))
+			}
+			sort.Strings(replaces)
+			add(source.name+" patcher", DiagnosticOK, "%s", strings.Join(replaces, ", "))
+		}
+
+		if _, err := os.Stat(path.Join(source.actions.DirPath, shuttlefolder.DistDir)); err != nil {
+			continue
+		}
+		prebuiltPath, ok, err := compile.FindPrebuilt(ctx, source.actions)
+		switch {
+		case err != nil:
+			add(source.name+" prebuilt", DiagnosticError, "failed to look for prebuilt binaries: %v", err)
+		case ok:
+			prebuilt = true
+			add(source.name+" prebuilt", DiagnosticOK, "%s matches the sources", prebuiltPath)
+		default:
+			add(
+				source.name+" prebuilt",
+				DiagnosticWarning,
+				"%s/%s has no binary for %s/%s built from the current sources, run shuttle actions export",
+				source.actions.DirPath,
+				shuttlefolder.DistDir,
+				runtime.GOOS,
+				runtime.GOARCH,
+			)
+		}
+	}
+
+	if !found || (!hasBuilder && !prebuilt) {
+		return diagnostics
+	}
+
+	binaries, err := compile.Compile(ctx, ui, disc)
+	if err != nil {
+		add("compile", DiagnosticError, "%v", err)
+		return diagnostics
+	}
+
+	for _, binary := range []compile.Binary{binaries.Local, binaries.Plan} {
+		if binary.Path == "" {
+			continue
+		}
+
+		actions, err := inquire(ctx, &binary)
+		if err != nil {
+			add("compile", DiagnosticError, "%s: %v", binary.Path, err)
+			continue
+		}
+		add(
+			"compile",
+			DiagnosticOK,
+			"%s: %d actions, protocol v%d",
+			binary.Path,
+			len(actions.Actions.Actions),
+			actions.ProtocolVersion,
+		)
+	}
+
+	return diagnostics
+}
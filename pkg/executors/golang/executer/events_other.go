@@ -0,0 +1,6 @@
+//go:build !unix
+
+package executer
+
+// eventsSupported is whether events are read from golang actions, see events_unix.go
+const eventsSupported = false
@@ -0,0 +1,7 @@
+//go:build unix
+
+package executer
+
+// eventsSupported is whether events are read from golang actions. The events pipe is passed as an extra file, which
+// os/exec only supports on unix
+const eventsSupported = true
@@ -1,67 +1,115 @@
 package executer
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	shuttleerrors "github.com/lunarway/shuttle/pkg/errors"
 	"github.com/lunarway/shuttle/pkg/executors/golang/compile"
+	"github.com/lunarway/shuttle/pkg/executors/golang/protocol"
+	"github.com/lunarway/shuttle/pkg/sdk"
 	"github.com/lunarway/shuttle/pkg/telemetry"
+	"github.com/lunarway/shuttle/pkg/ui"
 )
 
-// Executes an action based on which plan is used
-// Get a list of actions for each binary if they exist
-// Take child if available otherwise pick plan, else error
-func executeAction(ctx context.Context, binaries *compile.Binaries, args ...string) error {
+// eventsFD is the file descriptor of the events pipe in the binary, the first one after stdin, stdout and stderr
+const eventsFD = 3
+
+// gracePeriod is how long an action has to clean up after being signalled, before it is killed
+var gracePeriod = 10 * time.Second
+
+// executeAction runs the action of args with the local binary if it declares it, otherwise with the plan binary, and
+// fails if neither does. It returns the value returned by the action, if any
+func executeAction(
+	ctx context.Context,
+	ui *ui.UI,
+	binaries *compile.Binaries,
+	contextFile string,
+	env []string,
+	args ...string,
+) (json.RawMessage, error) {
 	localInquire, err := inquire(ctx, &binaries.Local)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	planInquire, err := inquire(ctx, &binaries.Plan)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	cmdToExecute := args[0]
 
+	var value json.RawMessage
 	ran, err := localInquire.Execute(cmdToExecute, func() error {
-		return executeBinaryAction(ctx, &binaries.Local, args...)
+		value, err = executeBinaryAction(ctx, ui, &binaries.Local, localInquire.Handshake, contextFile, env, args...)
+		return err
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if ran {
-		return nil
+		return value, nil
 	}
 
 	ran, err = planInquire.Execute(cmdToExecute, func() error {
-		return executeBinaryAction(ctx, &binaries.Plan, args...)
+		value, err = executeBinaryAction(ctx, ui, &binaries.Plan, planInquire.Handshake, contextFile, env, args...)
+		return err
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if ran {
-		return nil
+		return value, nil
 	}
 
-	return fmt.Errorf("no action available in commands, available options are available through shuttle run -h")
+	return nil, fmt.Errorf("no action available in commands, available options are available through shuttle run -h")
 }
 
-func executeBinaryAction(ctx context.Context, binary *compile.Binary, args ...string) error {
-	execmd := exec.Command(binary.Path, args...)
-	execmd.Stdout = os.Stdout
-	execmd.Stderr = os.Stderr
+// executeBinaryAction runs an action of binary. Its output is shown like the output of shell actions, and if the
+// binary supports events, its logs, progress and result are reported through ui as well
+func executeBinaryAction(
+	ctx context.Context,
+	ui *ui.UI,
+	binary *compile.Binary,
+	handshake protocol.Handshake,
+	contextFile string,
+	env []string,
+	args ...string,
+) (json.RawMessage, error) {
+	action := args[0]
+
+	execmd := exec.CommandContext(ctx, binary.Path, args...)
+	execmd.Cancel = func() error {
+		ui.Verboseln("stopping golang action %s, it is killed if still running in %s", action, gracePeriod)
+		return execmd.Process.Signal(cancelSignal(ctx))
+	}
+	// bounds waiting for the output of processes started by the action as well
+	execmd.WaitDelay = gracePeriod
+	execmd.Stdin = os.Stdin
+	stdout := &lineWriter{output: func(line string) { ui.Output("%s", line) }}
+	stderr := &lineWriter{output: func(line string) { ui.Infoln("%s", line) }}
+	execmd.Stdout = stdout
+	execmd.Stderr = stderr
 
 	workdir, err := os.Getwd()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	execmd.Env = os.Environ()
 	execmd.Env = append(execmd.Env, fmt.Sprintf("TASK_CONTEXT_DIR=%s", workdir))
 	execmd.Env = append(execmd.Env, "SHUTTLE_INTERACTIVE=default")
+	execmd.Env = append(execmd.Env, fmt.Sprintf("%s=%s", sdk.ShuttleContextFileEnv, contextFile))
 	execmd.Env = append(
 		execmd.Env,
 		fmt.Sprintf("%s=%s",
@@ -69,26 +117,200 @@ func executeBinaryAction(ctx context.Context, binary *compile.Binary, args ...st
 			telemetry.ContextIDFrom(ctx),
 		),
 	)
+	execmd.Env = append(execmd.Env, env...)
 
-	err = execmd.Run()
+	var events, eventsWriter *os.File
+	if eventsSupported && handshake.Has(protocol.CapabilityEvents) {
+		events, eventsWriter, err = os.Pipe()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create events pipe: %w", err)
+		}
+		defer events.Close()
 
-	os.Stdout.Sync()
-	os.Stderr.Sync()
+		execmd.ExtraFiles = []*os.File{eventsWriter}
+		execmd.Env = append(execmd.Env, fmt.Sprintf("%s=%d", protocol.EventsFDEnv, eventsFD))
+	}
 
+	start := time.Now()
+	err = execmd.Start()
+	if eventsWriter != nil {
+		// the binary has its own copy, closing ours signals the end of the events when the binary exits
+		eventsWriter.Close()
+	}
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to start golang action %s: %w", action, err)
+	}
+
+	var (
+		result    *protocol.Result
+		eventsErr error
+	)
+	eventsRead := make(chan struct{})
+	go func() {
+		defer close(eventsRead)
+		if events != nil {
+			result, eventsErr = readEvents(ui, events)
+		}
+	}()
+
+	err = execmd.Wait()
+	stdout.Flush()
+	stderr.Flush()
+	<-eventsRead
+
+	if eventsErr != nil {
+		ui.Verboseln("failed to read events of golang action %s: %v", action, eventsErr)
+	}
+
+	// like shell actions, a cancelled action fails with the error of the context
+	if ctx.Err() != nil {
+		err = ctx.Err()
+	} else {
+		err = actionError(action, err, result)
+	}
+	traceAction(ctx, action, time.Since(start), err)
+	if err != nil {
+		return nil, err
+	}
+
+	if result != nil {
+		return result.Value, nil
+	}
+	return nil, nil
+}
+
+// cancelSignal is the signal stopping an action when ctx is done. Signals received by shuttle are forwarded, if the
+// cause of ctx carries one
+func cancelSignal(ctx context.Context) os.Signal {
+	var signalErr interface{ Signal() os.Signal }
+	if errors.As(context.Cause(ctx), &signalErr) {
+		return signalErr.Signal()
+	}
+
+	return os.Interrupt
+}
+
+// actionError formats a failed action like a failed shell action. The result of the binary is preferred, as it holds
+// the error returned by the action
+func actionError(action string, runErr error, result *protocol.Result) error {
+	if result != nil && !result.Success {
+		return shuttleerrors.NewExitCode(4, "Failed executing golang action `%s`: %s", action, result.Error)
+	}
+	if runErr == nil {
+		return nil
 	}
 
-	return nil
+	var exitErr *exec.ExitError
+	if errors.As(runErr, &exitErr) {
+		return shuttleerrors.NewExitCode(
+			4,
+			"Failed executing golang action `%s`\nExit code: %d",
+			action,
+			exitErr.ExitCode(),
+		)
+	}
+
+	return fmt.Errorf("failed executing golang action %s: %w", action, runErr)
 }
 
-func inquire(ctx context.Context, binary *compile.Binary) (actions *Actions, err error) {
+func traceAction(ctx context.Context, action string, duration time.Duration, err error) {
+	options := []telemetry.TelemetryOption{
+		telemetry.WithEntry("action", action),
+		telemetry.WithEntry("duration_ms", strconv.FormatInt(duration.Milliseconds(), 10)),
+	}
+	if err != nil {
+		telemetry.TraceError(ctx, "golang_action", err, options...)
+		return
+	}
+	telemetry.Trace(ctx, "golang_action", append(options, telemetry.WithPhase("end"))...)
+}
+
+// lineWriter passes each line written to it on to output
+type lineWriter struct {
+	mu     sync.Mutex
+	buf    []byte
+	output func(line string)
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.output(strings.TrimSuffix(string(w.buf[:i]), "\r"))
+		w.buf = w.buf[i+1:]
+	}
+
+	return len(p), nil
+}
+
+// Flush outputs the last line, if it wasn't terminated by a newline
+func (w *lineWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.buf) > 0 {
+		w.output(string(w.buf))
+		w.buf = nil
+	}
+}
+
+// readEvents shows the events of a binary through ui, and returns the result of the action if it was reported
+func readEvents(ui *ui.UI, r io.Reader) (*protocol.Result, error) {
+	decoder := protocol.NewDecoder(r)
+
+	var result *protocol.Result
+	for {
+		event, err := decoder.Decode()
+		if err != nil {
+			_, _ = io.Copy(io.Discard, r)
+			if errors.Is(err, io.EOF) {
+				return result, nil
+			}
+			return result, err
+		}
+
+		switch event.Type {
+		case protocol.EventLog:
+			switch event.Level {
+			case protocol.LevelDebug:
+				ui.Verboseln("%s", event.Message)
+			case protocol.LevelWarn:
+				ui.Infoln("warning: %s", event.Message)
+			case protocol.LevelError:
+				ui.Errorln("%s", event.Message)
+			default:
+				ui.Infoln("%s", event.Message)
+			}
+		case protocol.EventProgress:
+			if event.Progress == nil {
+				continue
+			}
+			if event.Progress.Total > 0 {
+				ui.Infoln("[%d/%d] %s", event.Progress.Current, event.Progress.Total, event.Progress.Step)
+			} else {
+				ui.Infoln("%s", event.Progress.Step)
+			}
+		case protocol.EventResult:
+			result = event.Result
+		default:
+			// events added by later versions of the protocol are ignored
+		}
+	}
+}
+
+func inquire(ctx context.Context, binary *compile.Binary) (*Inquiry, error) {
 	if binary == nil {
-		return nil, nil
+		return &Inquiry{}, nil
 	}
 
 	if binary.Path == "" {
-		return nil, nil
+		return &Inquiry{}, nil
 	}
 
 	cmd := exec.Command(binary.Path, "lsjson")
@@ -103,10 +325,16 @@ func inquire(ctx context.Context, binary *compile.Binary) (actions *Actions, err
 
 	}
 
-	actions = &Actions{}
-	if err := json.Unmarshal(output, actions); err != nil {
+	inquiry := &Inquiry{}
+	if err := json.Unmarshal(output, inquiry); err != nil {
 		return nil, fmt.Errorf("inquire failed with json unmarshal: %v", err)
 	}
+	if err := inquiry.Check(); err != nil {
+		return nil, fmt.Errorf("%s: %w", binary.Path, err)
+	}
+	if inquiry.Actions == nil {
+		inquiry.Actions = NewActions()
+	}
 
-	return actions, nil
+	return inquiry, nil
 }
@@ -0,0 +1,191 @@
+package executer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	shuttleerrors "github.com/lunarway/shuttle/pkg/errors"
+	"github.com/lunarway/shuttle/pkg/executors/golang/compile"
+	"github.com/lunarway/shuttle/pkg/executors/golang/protocol"
+	"github.com/lunarway/shuttle/pkg/ui"
+)
+
+// fakeBinary is an actions binary responding to lsjson with output
+func fakeBinary(t *testing.T, output string) *compile.Binary {
+	t.Helper()
+
+	binaryPath := path.Join(t.TempDir(), "actions")
+	script := "#!/bin/sh\ncat <<'EOF'\n" + output + "\nEOF\n"
+	require.NoError(t, os.WriteFile(binaryPath, []byte(script), 0o755))
+
+	return &compile.Binary{Path: binaryPath}
+}
+
+func TestInquire(t *testing.T) {
+	t.Run("current protocol", func(t *testing.T) {
+		binary := fakeBinary(t, `{"protocolVersion":1,"capabilities":["events"],"actions":{"build":{"args":[]}}}`)
+
+		inquiry, err := inquire(context.Background(), binary)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, inquiry.ProtocolVersion)
+		assert.True(t, inquiry.Has(protocol.CapabilityEvents))
+		assert.Contains(t, inquiry.Actions.Actions, "build")
+	})
+
+	t.Run("legacy binary", func(t *testing.T) {
+		binary := fakeBinary(t, `{"actions":{"build":{"args":[]}}}`)
+
+		inquiry, err := inquire(context.Background(), binary)
+
+		require.NoError(t, err)
+		assert.Equal(t, protocol.Legacy, inquiry.ProtocolVersion)
+		assert.False(t, inquiry.Has(protocol.CapabilityEvents))
+		assert.Contains(t, inquiry.Actions.Actions, "build")
+	})
+
+	t.Run("newer protocol", func(t *testing.T) {
+		binary := fakeBinary(t, `{"protocolVersion":99,"actions":{}}`)
+
+		_, err := inquire(context.Background(), binary)
+
+		assert.ErrorIs(t, err, protocol.ErrIncompatible)
+	})
+
+	t.Run("no binary", func(t *testing.T) {
+		inquiry, err := inquire(context.Background(), &compile.Binary{})
+
+		require.NoError(t, err)
+		ran, err := inquiry.Execute("build", func() error { return nil })
+		assert.NoError(t, err)
+		assert.False(t, ran)
+	})
+}
+
+func TestReadEvents(t *testing.T) {
+	var buf bytes.Buffer
+	enc := protocol.NewEncoder(&buf)
+	for _, event := range []protocol.Event{
+		{Type: protocol.EventLog, Level: protocol.LevelDebug, Message: "debug"},
+		{Type: protocol.EventLog, Level: protocol.LevelInfo, Message: "info"},
+		{Type: protocol.EventLog, Level: protocol.LevelWarn, Message: "warn"},
+		{Type: protocol.EventLog, Level: protocol.LevelError, Message: "error"},
+		{Type: protocol.EventProgress, Progress: &protocol.Progress{Step: "step", Current: 1, Total: 2}},
+		{Type: "unknown", Message: "ignored"},
+		{Type: protocol.EventResult, Result: &protocol.Result{Action: "build", Error: "failed"}},
+	} {
+		require.NoError(t, enc.Encode(event))
+	}
+
+	var stdout, stderr bytes.Buffer
+	uii := ui.Create(&stdout, &stderr)
+
+	result, err := readEvents(uii, &buf)
+
+	require.NoError(t, err)
+	assert.Equal(t, &protocol.Result{Action: "build", Error: "failed"}, result)
+	assert.Empty(t, stdout.String())
+	assert.Equal(t, []string{
+		"info",
+		"warning: warn",
+		"\x1b[31;1merror\x1b[0m",
+		"[1/2] step",
+	}, strings.Split(strings.TrimSpace(stderr.String()), "\n"))
+}
+
+func TestActionError(t *testing.T) {
+	exitErr := exec.Command("sh", "-c", "exit 3").Run()
+	require.Error(t, exitErr)
+
+	t.Run("succeeded", func(t *testing.T) {
+		assert.NoError(t, actionError("build", nil, &protocol.Result{Success: true}))
+	})
+
+	t.Run("result error", func(t *testing.T) {
+		err := actionError("build", exitErr, &protocol.Result{Error: "some-error"})
+
+		var exitCode *shuttleerrors.ExitCode
+		require.True(t, errors.As(err, &exitCode))
+		assert.Equal(t, 4, exitCode.Code)
+		assert.Equal(t, "Failed executing golang action `build`: some-error", exitCode.Message)
+	})
+
+	t.Run("exit without result", func(t *testing.T) {
+		err := actionError("build", exitErr, nil)
+
+		var exitCode *shuttleerrors.ExitCode
+		require.True(t, errors.As(err, &exitCode))
+		assert.Equal(t, "Failed executing golang action `build`\nExit code: 3", exitCode.Message)
+	})
+}
+
+func TestLineWriter(t *testing.T) {
+	lines := make([]string, 0)
+	w := &lineWriter{output: func(line string) { lines = append(lines, line) }}
+
+	_, _ = w.Write([]byte("first\r\nsec"))
+	_, _ = w.Write([]byte("ond\n\nlast"))
+	assert.Equal(t, []string{"first", "second", ""}, lines)
+
+	w.Flush()
+	assert.Equal(t, []string{"first", "second", "", "last"}, lines)
+}
+
+type testSignalError struct{}
+
+func (testSignalError) Error() string     { return "signal" }
+func (testSignalError) Signal() os.Signal { return syscall.SIGTERM }
+
+func TestCancelSignal(t *testing.T) {
+	t.Run("forwards the signal received by shuttle", func(t *testing.T) {
+		ctx, cancel := context.WithCancelCause(context.Background())
+		cancel(fmt.Errorf("stopping: %w", testSignalError{}))
+
+		assert.Equal(t, syscall.SIGTERM, cancelSignal(ctx))
+	})
+
+	t.Run("interrupts on other causes", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+		defer cancel()
+		<-ctx.Done()
+
+		assert.Equal(t, os.Interrupt, cancelSignal(ctx))
+	})
+}
+
+func TestExecuteBinaryActionKillsAfterGracePeriod(t *testing.T) {
+	previous := gracePeriod
+	gracePeriod = 100 * time.Millisecond
+	t.Cleanup(func() { gracePeriod = previous })
+
+	binaryPath := path.Join(t.TempDir(), "actions")
+	// the action ignores signals, and starts a process keeping stdout open
+	script := "#!/bin/sh\ntrap '' INT TERM\necho started\nsleep 10\n"
+	require.NoError(t, os.WriteFile(binaryPath, []byte(script), 0o755))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(200*time.Millisecond, cancel)
+
+	var stdout bytes.Buffer
+	uii := ui.Create(&stdout, io.Discard)
+
+	start := time.Now()
+	_, err := executeBinaryAction(ctx, uii, &compile.Binary{Path: binaryPath}, protocol.Handshake{}, "", nil, "wait")
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.Equal(t, "started\n", stdout.String())
+}
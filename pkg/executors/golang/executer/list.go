@@ -40,8 +40,8 @@ func List(
 	}
 
 	actions := NewActions().
-		Merge(localInquire).
-		Merge(planInquire)
+		Merge(localInquire.Actions).
+		Merge(planInquire.Actions)
 
 	return actions, nil
 }
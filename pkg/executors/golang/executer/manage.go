@@ -0,0 +1,121 @@
+package executer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+
+	"github.com/lunarway/shuttle/pkg/config"
+	"github.com/lunarway/shuttle/pkg/executors/golang/compile"
+	"github.com/lunarway/shuttle/pkg/executors/golang/discover"
+	"github.com/lunarway/shuttle/pkg/ui"
+)
+
+// IsEnabled reports whether golang actions are enabled by SHUTTLE_GOLANG_ACTIONS
+func IsEnabled() bool {
+	return isActionsEnabled()
+}
+
+// Build compiles the actions binaries of the project and its plan, even if cached binaries match
+func Build(
+	ctx context.Context,
+	ui *ui.UI,
+	path string,
+	c *config.ShuttleProjectContext,
+) (*compile.Binaries, error) {
+	disc, err := discover.Discover(ctx, path, c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover actions: %w", err)
+	}
+
+	binaries, err := compile.Compile(ctx, ui, disc, compile.WithForce())
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile binaries: %w", err)
+	}
+
+	return binaries, nil
+}
+
+// Export builds prebuilt binaries of the actions of the project for platforms into actions/dist. Plans ship these, so
+// projects using the plan don't have to compile its actions
+func Export(
+	ctx context.Context,
+	ui *ui.UI,
+	path string,
+	c *config.ShuttleProjectContext,
+	platforms []compile.Platform,
+) ([]string, error) {
+	disc, err := discover.Discover(ctx, path, c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover actions: %w", err)
+	}
+	if disc.Local == nil {
+		return nil, errors.New("no golang actions found in the project")
+	}
+
+	exported, err := compile.Export(ctx, ui, disc.Local, platforms)
+	if err != nil {
+		return exported, fmt.Errorf("failed to export binaries: %w", err)
+	}
+
+	return exported, nil
+}
+
+// ListBySource returns the actions of the project and its plan separately. The projects actions take precedence
+// over the plans actions of the same name
+func ListBySource(
+	ctx context.Context,
+	ui *ui.UI,
+	path string,
+	c *config.ShuttleProjectContext,
+) (local *Actions, plan *Actions, err error) {
+	binaries, err := prepare(ctx, ui, path, c)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	localInquire, err := inquire(ctx, &binaries.Local)
+	if err != nil {
+		return nil, nil, err
+	}
+	planInquire, err := inquire(ctx, &binaries.Plan)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return NewActions().Merge(localInquire.Actions), NewActions().Merge(planInquire.Actions), nil
+}
+
+// Clean removes the binaries and build directories of the actions of the project and its plan, and returns the removed
+// directories
+func Clean(ctx context.Context, path string, c *config.ShuttleProjectContext) ([]string, error) {
+	disc, err := discover.Discover(ctx, path, c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover actions: %w", err)
+	}
+
+	removed := make([]string, 0)
+	for _, actions := range []*discover.ActionsDiscovered{disc.Local, disc.Plan} {
+		if actions == nil {
+			continue
+		}
+
+		shuttlelocaldir := shuttleActionsDir(actions)
+		if _, err := os.Stat(shuttlelocaldir); err != nil {
+			continue
+		}
+		if err := os.RemoveAll(shuttlelocaldir); err != nil {
+			return removed, err
+		}
+		removed = append(removed, shuttlelocaldir)
+	}
+
+	return removed, nil
+}
+
+// shuttleActionsDir is where binaries and the build directory of actions are stored
+func shuttleActionsDir(actions *discover.ActionsDiscovered) string {
+	return path.Join(actions.ParentDir, ".shuttle/actions")
+}
@@ -0,0 +1,67 @@
+package executer
+
+import (
+	"context"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lunarway/shuttle/pkg/config"
+)
+
+func TestScaffold(t *testing.T) {
+	t.Run("creates actions module", func(t *testing.T) {
+		projectPath := t.TempDir()
+
+		created, err := Scaffold(projectPath)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{
+			path.Join(projectPath, "actions", "go.mod"),
+			path.Join(projectPath, "actions", "hello.go"),
+		}, created)
+		for _, file := range created {
+			assert.FileExists(t, file)
+		}
+	})
+
+	t.Run("never overwrites files", func(t *testing.T) {
+		projectPath := t.TempDir()
+		require.NoError(t, os.MkdirAll(path.Join(projectPath, "actions"), 0o755))
+		require.NoError(t, os.WriteFile(path.Join(projectPath, "actions", "hello.go"), []byte("package main"), 0o644))
+
+		_, err := Scaffold(projectPath)
+		assert.ErrorContains(t, err, "hello.go already exists")
+
+		content, err := os.ReadFile(path.Join(projectPath, "actions", "hello.go"))
+		require.NoError(t, err)
+		assert.Equal(t, "package main", string(content))
+		assert.NoFileExists(t, path.Join(projectPath, "actions", "go.mod"))
+	})
+}
+
+func TestClean(t *testing.T) {
+	projectPath := t.TempDir()
+	_, err := Scaffold(projectPath)
+	require.NoError(t, err)
+
+	binaries := path.Join(projectPath, ".shuttle", "actions", "binaries")
+	require.NoError(t, os.MkdirAll(binaries, 0o755))
+	require.NoError(t, os.WriteFile(path.Join(binaries, "actions-abc"), []byte("binary"), 0o755))
+
+	c := &config.ShuttleProjectContext{ProjectPath: projectPath}
+	shuttlepath := path.Join(projectPath, "shuttle.yaml")
+	require.NoError(t, os.WriteFile(shuttlepath, []byte("plan: false\n"), 0o644))
+
+	removed, err := Clean(context.Background(), shuttlepath, c)
+	require.NoError(t, err)
+	assert.Equal(t, []string{path.Join(projectPath, ".shuttle", "actions")}, removed)
+	assert.NoDirExists(t, path.Join(projectPath, ".shuttle", "actions"))
+
+	removed, err = Clean(context.Background(), shuttlepath, c)
+	require.NoError(t, err)
+	assert.Empty(t, removed)
+}
@@ -2,13 +2,34 @@ package executer
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"os"
 
 	"github.com/lunarway/shuttle/pkg/config"
 	golangerrors "github.com/lunarway/shuttle/pkg/executors/golang/errors"
+	"github.com/lunarway/shuttle/pkg/sdk"
 	"github.com/lunarway/shuttle/pkg/ui"
 )
 
+// writeShuttleContextFile passes the configuration loaded by shuttle on to the actions binary, so actions don't have to
+// load shuttle.yaml themselves
+func writeShuttleContextFile(c *config.ShuttleProjectContext) (string, error) {
+	file, err := os.CreateTemp("", "shuttle-context-*.yaml")
+	if err != nil {
+		return "", fmt.Errorf("failed to create shuttle context file: %w", err)
+	}
+	file.Close()
+
+	if err := sdk.WriteShuttleContextFile(file.Name(), sdk.NewShuttleContext(c)); err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("failed to write shuttle context file: %w", err)
+	}
+
+	return file.Name(), nil
+}
+
 func Run(
 	ctx context.Context,
 	ui *ui.UI,
@@ -16,25 +37,41 @@ func Run(
 	path string,
 	args ...string,
 ) error {
+	_, err := RunWithValue(ctx, ui, c, path, nil, args...)
+	return err
+}
+
+// RunWithValue runs the action of args like Run, with env added to its environment. It returns the JSON of the value
+// returned by actions with a (T, error) signature, and nil for actions only returning an error
+func RunWithValue(
+	ctx context.Context,
+	ui *ui.UI,
+	c *config.ShuttleProjectContext,
+	path string,
+	env []string,
+	args ...string,
+) (json.RawMessage, error) {
 	if !isActionsEnabled() {
 		ui.Verboseln("shuttle golang actions disabled")
-		return nil
+		return nil, nil
 	}
 
 	binaries, err := prepare(ctx, ui, path, c)
 	if err != nil {
 		if errors.Is(err, golangerrors.ErrGolangActionNoBuilder) {
-			return nil
+			return nil, nil
 		}
 
 		ui.Errorln("failed to run command: %v", err)
-		return err
+		return nil, err
 	}
 
-	ui.Verboseln("executing shuttle golang actions")
-	if err := executeAction(ctx, binaries, args...); err != nil {
-		return err
+	contextFile, err := writeShuttleContextFile(c)
+	if err != nil {
+		return nil, err
 	}
+	defer os.Remove(contextFile)
 
-	return nil
+	ui.Verboseln("executing shuttle golang actions")
+	return executeAction(ctx, ui, binaries, contextFile, env, args...)
 }
@@ -1,10 +1,12 @@
 package executer_test
 
 import (
+	"bytes"
 	"context"
 	"log"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"testing"
 
 	"github.com/lunarway/shuttle/pkg/config"
@@ -41,9 +43,15 @@ func TestRunVersion(t *testing.T) {
 	assert.Error(t, err)
 }
 
+// updateShuttle builds the actions of the project in path. The .shuttle directory is removed when the test finishes, so
+// it isn't left in testdata
 func updateShuttle(t *testing.T, path string) {
-	err := os.RemoveAll("testdata/child/.shuttle/")
+	shuttleDir := filepath.Join(path, ".shuttle")
+	err := os.RemoveAll(shuttleDir)
 	assert.NoError(t, err)
+	t.Cleanup(func() {
+		assert.NoError(t, os.RemoveAll(shuttleDir))
+	})
 
 	shuttleCmd := exec.Command("shuttle", "ls")
 	shuttleCmd.Dir = path
@@ -52,3 +60,18 @@ func updateShuttle(t *testing.T, path string) {
 		assert.Error(t, err)
 	}
 }
+
+func TestTest(t *testing.T) {
+	updateShuttle(t, "testdata/child")
+	ctx := context.Background()
+
+	c := &config.ShuttleProjectContext{Config: config.ShuttleConfig{Plan: "something"}}
+
+	var stdout, stderr bytes.Buffer
+	ui := ui.Create(&stdout, &stderr)
+
+	err := executer.Test(ctx, ui, "testdata/child/shuttle.yaml", c, []string{"-v"})
+
+	assert.NoError(t, err)
+	assert.Contains(t, stdout.String(), "--- PASS: TestVersion")
+}
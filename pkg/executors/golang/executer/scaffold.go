@@ -0,0 +1,65 @@
+package executer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+)
+
+const scaffoldGoMod = `module actions
+
+go 1.22
+`
+
+const scaffoldAction = `package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// Hello greets someone. Run it with shuttle run hello --name shuttle
+//
+// Args:
+//   - name (default: world): who to greet
+func Hello(ctx context.Context, name string) error {
+	fmt.Printf("Hello, %s!\n", name)
+	return nil
+}
+`
+
+// Scaffold creates an actions module with a sample action in projectPath, and returns the created files.
+// Existing files are never overwritten
+func Scaffold(projectPath string) ([]string, error) {
+	actionsDir := path.Join(projectPath, "actions")
+	files := []struct {
+		name    string
+		content string
+	}{
+		{name: path.Join(actionsDir, "go.mod"), content: scaffoldGoMod},
+		{name: path.Join(actionsDir, "hello.go"), content: scaffoldAction},
+	}
+
+	for _, file := range files {
+		if _, err := os.Stat(file.name); err == nil {
+			return nil, fmt.Errorf("%s already exists", file.name)
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	if err := os.MkdirAll(actionsDir, 0o755); err != nil {
+		return nil, err
+	}
+
+	created := make([]string, 0, len(files))
+	for _, file := range files {
+		if err := os.WriteFile(file.name, []byte(file.content), 0o644); err != nil {
+			return created, err
+		}
+		created = append(created, file.name)
+	}
+
+	return created, nil
+}
@@ -0,0 +1,93 @@
+package executer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/lunarway/shuttle/pkg/config"
+	shuttleerrors "github.com/lunarway/shuttle/pkg/errors"
+	"github.com/lunarway/shuttle/pkg/executors/golang/compile"
+	"github.com/lunarway/shuttle/pkg/executors/golang/discover"
+	golangerrors "github.com/lunarway/shuttle/pkg/executors/golang/errors"
+	"github.com/lunarway/shuttle/pkg/sdk"
+	"github.com/lunarway/shuttle/pkg/ui"
+)
+
+// Test runs the tests of the actions of the project and its plan with go test, in the same workspace the actions
+// binaries are built in. args are passed on to go test, i.e. -run TestDeploy
+func Test(
+	ctx context.Context,
+	ui *ui.UI,
+	path string,
+	c *config.ShuttleProjectContext,
+	args []string,
+) error {
+	disc, err := discover.Discover(ctx, path, c)
+	if err != nil {
+		return fmt.Errorf("failed to discover actions: %w", err)
+	}
+	if disc.Local == nil && disc.Plan == nil {
+		ui.Infoln("No golang actions found")
+		return nil
+	}
+
+	// tests see the same configuration as the actions run by shuttle
+	contextFile, err := writeShuttleContextFile(c)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(contextFile)
+
+	for _, actions := range []*discover.ActionsDiscovered{disc.Local, disc.Plan} {
+		if actions == nil {
+			continue
+		}
+
+		ui.Titleln("Testing golang actions in %s", actions.DirPath)
+		workspace, err := compile.PrepareWorkspace(ctx, ui, actions)
+		if err != nil {
+			if errors.Is(err, golangerrors.ErrGolangActionNoBuilder) {
+				return errors.New("go is required to test golang actions")
+			}
+			return err
+		}
+
+		if err := goTest(ctx, ui, workspace, contextFile, args); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return shuttleerrors.NewExitCode(4, "Tests of golang actions in %s failed: %v", actions.DirPath, err)
+		}
+	}
+
+	return nil
+}
+
+func goTest(ctx context.Context, ui *ui.UI, workspace string, contextFile string, args []string) error {
+	testcmd := exec.CommandContext(ctx, "go", append([]string{"test", "./..."}, args...)...)
+	testcmd.Cancel = func() error {
+		return testcmd.Process.Signal(cancelSignal(ctx))
+	}
+	testcmd.WaitDelay = gracePeriod
+	testcmd.Dir = workspace
+
+	testcmd.Env = os.Environ()
+	// We need to set workspaces off, as we don't want users to have to add the golang modules to their go.work
+	testcmd.Env = append(testcmd.Env, "GOWORK=off")
+	testcmd.Env = append(testcmd.Env, fmt.Sprintf("%s=%s", sdk.ShuttleContextFileEnv, contextFile))
+
+	stdout := &lineWriter{output: func(line string) { ui.Output("%s", line) }}
+	stderr := &lineWriter{output: func(line string) { ui.Infoln("%s", line) }}
+	testcmd.Stdout = stdout
+	testcmd.Stderr = stderr
+
+	ui.Verboseln("running go test in %s", workspace)
+	err := testcmd.Run()
+	stdout.Flush()
+	stderr.Flush()
+
+	return err
+}
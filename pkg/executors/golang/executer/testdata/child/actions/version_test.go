@@ -0,0 +1,14 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/lunarway/shuttle/pkg/executors/golang/cmder/cmdertest"
+)
+
+func TestVersion(t *testing.T) {
+	result := cmdertest.Execute(t, newShuttleRootCmd(), []string{"version"})
+	if result.Err != nil {
+		t.Fatal(result.Err)
+	}
+}
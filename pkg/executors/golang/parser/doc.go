@@ -0,0 +1,84 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+// argsHeading starts the section of a doc comment describing the parameters of an action
+const argsHeading = "Args:"
+
+// argLine matches a parameter in the Args section, i.e.
+//
+//   - replicas: number of replicas to run
+//   - dryRun (optional): only print what would happen
+//   - timeout (default: 5m): how long to wait
+var argLine = regexp.MustCompile(`^[-*]?\s*(\w+)\s*(?:\(([^)]*)\))?\s*:\s*(.*)$`)
+
+type argDoc struct {
+	description string
+	optional    bool
+	required    bool
+	def         string
+}
+
+// defaultMarker is the last marker of a parameter, as the value may contain commas
+var defaultMarker = regexp.MustCompile(`(?:^|,)\s*default:\s*(.*)$`)
+
+// parseDoc splits a functions doc comment into the action description and the documentation of each parameter.
+//
+// Parameters are documented in a section starting with "Args:", each parameter on its own line.
+// A parameter may be marked as (optional), (required) or (default: value), i.e. (optional, default: 5m)
+func parseDoc(doc string) (string, map[string]argDoc) {
+	args := make(map[string]argDoc)
+
+	description := make([]string, 0)
+	inArgs := false
+	var current string
+	for _, line := range strings.Split(doc, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == argsHeading {
+			inArgs = true
+			continue
+		}
+		if !inArgs {
+			description = append(description, line)
+			continue
+		}
+
+		if trimmed == "" {
+			current = ""
+			continue
+		}
+
+		match := argLine.FindStringSubmatch(trimmed)
+		if match == nil {
+			// continuation of the previous parameters description
+			if current != "" {
+				arg := args[current]
+				arg.description = strings.TrimSpace(arg.description + " " + trimmed)
+				args[current] = arg
+			}
+			continue
+		}
+
+		current = strings.ToLower(match[1])
+		arg := argDoc{description: match[3]}
+		markers := match[2]
+		if def := defaultMarker.FindStringSubmatchIndex(markers); def != nil {
+			arg.def = strings.TrimSpace(markers[def[2]:def[3]])
+			markers = markers[:def[0]]
+		}
+		for _, marker := range strings.Split(markers, ",") {
+			switch strings.TrimSpace(marker) {
+			case "optional":
+				arg.optional = true
+			case "required":
+				arg.required = true
+			}
+		}
+		args[current] = arg
+	}
+
+	return strings.TrimSpace(strings.Join(description, "\n")), args
+}
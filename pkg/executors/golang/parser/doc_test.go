@@ -0,0 +1,38 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDoc(t *testing.T) {
+	t.Run("no doc", func(t *testing.T) {
+		description, args := parseDoc("")
+
+		assert.Equal(t, "", description)
+		assert.Empty(t, args)
+	})
+
+	t.Run("description and args", func(t *testing.T) {
+		description, args := parseDoc(`Deploy rolls out the service.
+
+It waits for the rollout to finish.
+
+Args:
+  - replicas: number of replicas
+    to run
+  - dryRun (optional): only print what would happen
+  - env (required): environment to deploy to
+  - tags (optional, default: a,b): tags to apply
+`)
+
+		assert.Equal(t, "Deploy rolls out the service.\n\nIt waits for the rollout to finish.", description)
+		assert.Equal(t, map[string]argDoc{
+			"replicas": {description: "number of replicas to run"},
+			"dryrun":   {description: "only print what would happen", optional: true},
+			"env":      {description: "environment to deploy to", required: true},
+			"tags":     {description: "tags to apply", optional: true, def: "a,b"},
+		}, args)
+	})
+}
@@ -7,6 +7,8 @@ import (
 	"go/ast"
 	"go/parser"
 	"go/token"
+	"go/types"
+	"os"
 	"path"
 	"strings"
 
@@ -14,81 +16,333 @@ import (
 )
 
 type Function struct {
-	Name   string
-	Input  []Arg
-	Output Output
+	Name string
+	// Namespace is the subpackage of the actions dir declaring the function, i.e. db or db/schema.
+	// Empty for functions in the actions dir itself
+	Namespace string
+	// Description is the doc comment of the function, without the Args section
+	Description string
+	Input       []Arg
+	Output      Output
 }
 
 type Arg struct {
 	Name string
+	// Type is the go type expression of the parameter, i.e. int, []string or time.Duration
+	Type        string
+	Description string
+	// Optional and Required overrides whether the arg is required, which otherwise depends on its type
+	Optional bool
+	Required bool
+	Default  string
+}
+
+// HasMetadata reports whether the function has documentation which requires more than the minimal cmder api
+func (f *Function) HasMetadata() bool {
+	if f.Description != "" {
+		return true
+	}
+	for _, arg := range f.Input {
+		if arg.Description != "" || arg.Optional || arg.Required || arg.Default != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CommandName is the name of the action, i.e. build or db:migrate for Migrate in the db subpackage
+func (f *Function) CommandName() string {
+	name := strings.ToLower(f.Name)
+	if f.Namespace == "" {
+		return name
+	}
+
+	return strings.ReplaceAll(f.Namespace, "/", ":") + ":" + name
 }
 
 type Output struct {
 	Error bool
+	// Value is the go type expression of the value returned before the error, i.e. string or Version. Empty if the
+	// function only returns an error
+	Value string
 }
 
+// Error is a problem with an action, positioned in the source file declaring it
+type Error struct {
+	Pos token.Position
+	// Function is the name of the action, empty if the problem isn't specific to a function
+	Function string
+	Message  string
+}
+
+func (e *Error) Error() string {
+	if e.Function == "" {
+		return fmt.Sprintf("%s: %s", e.Pos, e.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s", e.Pos, e.Function, e.Message)
+}
+
+// GenerateAst parses the actions copied into the tmp dir of shuttlelocaldir. Every problem found is reported at once,
+// positioned in the files of the actions dir, joined with errors.Join
 func GenerateAst(
 	ctx context.Context,
 	shuttlelocaldir string,
 	actions *discover.ActionsDiscovered,
 ) ([]*Function, error) {
 	funcs := make([]*Function, 0)
+	errs := make([]error, 0)
+	parse := func(dir, taskfile, namespace string) {
+		src, err := os.ReadFile(path.Join(shuttlelocaldir, "tmp", dir, taskfile))
+		if err != nil {
+			errs = append(errs, err)
+			return
+		}
 
-	for _, taskfile := range actions.Files {
-		tknSet := token.NewFileSet()
-		astfile, err := parser.ParseFile(
-			tknSet,
-			path.Join(shuttlelocaldir, "tmp", taskfile),
-			nil,
-			parser.ParseComments,
-		)
+		// positions refer to the users files rather than the copies in the tmp dir
+		fileFuncs, err := parseFile(path.Join(actions.DirPath, dir, taskfile), src, namespace)
 		if err != nil {
-			return nil, err
+			errs = append(errs, err)
 		}
-		if ast.FileExports(astfile) {
-			decls := astfile.Decls
-			for _, decl := range decls {
-				funcdecl, ok := decl.(*ast.FuncDecl)
-				if ok {
-					f := Function{}
-					f.Name = funcdecl.Name.Name
-					param := funcdecl.Type
-					paramList := param.Params.List
-					for _, param := range paramList {
-						for _, name := range param.Names {
-							if name != nil &&
-								!strings.Contains(fmt.Sprintf("%s", param.Type), "Context") {
-								f.Input = append(f.Input, Arg{
-									Name: name.Name,
-								})
-							}
-						}
-					}
-					outputParam := param.Results
-					if outputParam != nil {
-						if len(outputParam.List) > 1 {
-							return nil, errors.New("only error is supported as an output param")
-						}
-						if len(outputParam.List) == 0 {
-							return nil, errors.New(
-								"output params are required, only error is supported",
-							)
-						}
-
-						for _, param := range outputParam.List {
-							if fmt.Sprintf("%s", param.Type) != "error" {
-								return nil, errors.New("output was not error")
-							}
-						}
-
-						f.Output = Output{Error: true}
-					}
-
-					funcs = append(funcs, &f)
-				}
-			}
+		funcs = append(funcs, fileFuncs...)
+	}
+
+	for _, taskfile := range actions.Files {
+		parse("", taskfile, "")
+	}
+	for _, pkg := range actions.Packages {
+		for _, taskfile := range pkg.Files {
+			parse(pkg.Dir, taskfile, pkg.Dir)
 		}
 	}
 
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
 	return funcs, nil
 }
+
+// parseFile returns the actions declared in filename, which are the exported top-level functions. Subpackages are
+// libraries as well, so only functions taking a context.Context as the first parameter are actions when namespace is
+// set.
+//
+// src is passed to go/parser, which reads filename if it is nil. All problems of the file are returned, joined with
+// errors.Join, along with the valid actions
+func parseFile(filename string, src any, namespace string) ([]*Function, error) {
+	funcs := make([]*Function, 0)
+
+	tknSet := token.NewFileSet()
+	astfile, err := parser.ParseFile(
+		tknSet,
+		filename,
+		src,
+		parser.ParseComments,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if namespace != "" && astfile.Name.Name == "main" {
+		return nil, &Error{
+			Pos:     tknSet.Position(astfile.Name.Pos()),
+			Message: fmt.Sprintf("package main can't be imported as namespace %s", namespace),
+		}
+	}
+
+	errs := make([]error, 0)
+	for _, decl := range astfile.Decls {
+		funcdecl, ok := decl.(*ast.FuncDecl)
+		if !ok || funcdecl.Recv != nil || !funcdecl.Name.IsExported() {
+			continue
+		}
+		if namespace != "" && !isNamespacedAction(funcdecl) {
+			continue
+		}
+
+		f, funcErrs := parseFunc(tknSet, funcdecl, namespace)
+		if len(funcErrs) > 0 {
+			errs = append(errs, funcErrs...)
+			continue
+		}
+		funcs = append(funcs, f)
+	}
+
+	return funcs, errors.Join(errs...)
+}
+
+// parseFunc returns the action declared by funcdecl, or every problem preventing it from being an action
+func parseFunc(tknSet *token.FileSet, funcdecl *ast.FuncDecl, namespace string) (*Function, []error) {
+	f := Function{
+		Name:      funcdecl.Name.Name,
+		Namespace: namespace,
+	}
+	errs := make([]error, 0)
+	fail := func(pos token.Pos, format string, args ...any) {
+		errs = append(errs, &Error{
+			Pos:      tknSet.Position(pos),
+			Function: f.Name,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	description, argDocs := parseDoc(funcdecl.Doc.Text())
+	f.Description = description
+
+	// cmder passes the context as the first argument of every action
+	if !isNamespacedAction(funcdecl) {
+		fail(funcdecl.Type.Params.Pos(), "first parameter must be a context.Context")
+	}
+
+	for _, param := range funcdecl.Type.Params.List {
+		for _, name := range param.Names {
+			if name == nil || isInjectedType(param.Type) {
+				continue
+			}
+
+			argType := types.ExprString(param.Type)
+			if !isSupportedArgType(param.Type) {
+				fail(param.Type.Pos(), "parameter %s has unsupported type %s", name.Name, argType)
+				continue
+			}
+
+			argDoc := argDocs[strings.ToLower(name.Name)]
+			f.Input = append(f.Input, Arg{
+				Name:        name.Name,
+				Type:        argType,
+				Description: argDoc.description,
+				Optional:    argDoc.optional,
+				Required:    argDoc.required,
+				Default:     argDoc.def,
+			})
+		}
+	}
+
+	if results := funcdecl.Type.Results; results != nil {
+		resultTypes := fieldTypes(results)
+		switch {
+		case len(resultTypes) == 0:
+			fail(results.Pos(), "output params are required, only error or (T, error) is supported")
+		case len(resultTypes) > 2:
+			fail(results.Pos(), "only error or (T, error) is supported as output params, got (%s)", joinTypes(resultTypes))
+		case types.ExprString(resultTypes[len(resultTypes)-1]) != "error":
+			last := resultTypes[len(resultTypes)-1]
+			fail(last.Pos(), "output was %s, the last output param must be error", types.ExprString(last))
+		case len(resultTypes) == 2 && !isSupportedValueType(resultTypes[0]):
+			fail(
+				resultTypes[0].Pos(),
+				"output value has unsupported type %s, only strings, structs and maps are supported",
+				types.ExprString(resultTypes[0]),
+			)
+		default:
+			f.Output = Output{Error: true}
+			if len(resultTypes) == 2 {
+				f.Output.Value = types.ExprString(resultTypes[0])
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return &f, nil
+}
+
+// fieldTypes returns the type of each field, i.e. string, string for (a, b string)
+func fieldTypes(fields *ast.FieldList) []ast.Expr {
+	list := make([]ast.Expr, 0, len(fields.List))
+	for _, field := range fields.List {
+		list = append(list, field.Type)
+		for i := 1; i < len(field.Names); i++ {
+			list = append(list, field.Type)
+		}
+	}
+	return list
+}
+
+// joinTypes formats exprs as a list of types, i.e. string, error
+func joinTypes(exprs []ast.Expr) string {
+	list := make([]string, 0, len(exprs))
+	for _, expr := range exprs {
+		list = append(list, types.ExprString(expr))
+	}
+	return strings.Join(list, ", ")
+}
+
+// isInjectedType reports whether parameters of the type are provided by cmder rather than args,
+// i.e. context.Context and sdk.ShuttleContext
+func isInjectedType(expr ast.Expr) bool {
+	switch types.ExprString(expr) {
+	case "context.Context", "sdk.ShuttleContext":
+		return true
+	default:
+		return false
+	}
+}
+
+func isNamespacedAction(funcdecl *ast.FuncDecl) bool {
+	if funcdecl.Recv != nil {
+		return false
+	}
+
+	params := funcdecl.Type.Params.List
+	return len(params) > 0 && types.ExprString(params[0].Type) == "context.Context"
+}
+
+// supportedArgTypes are the builtin types cmder can parse from flags
+var supportedArgTypes = map[string]bool{
+	"string":        true,
+	"int":           true,
+	"int64":         true,
+	"float64":       true,
+	"bool":          true,
+	"time.Duration": true,
+	"[]string":      true,
+}
+
+// isSupportedArgType allows the builtin types in supportedArgTypes, pointers to them and named types.
+// Named types are expected to be options structs, which cmder validates when the binary starts
+func isSupportedArgType(expr ast.Expr) bool {
+	if supportedArgTypes[types.ExprString(expr)] {
+		return true
+	}
+	if star, ok := expr.(*ast.StarExpr); ok {
+		return supportedArgTypes[types.ExprString(star.X)]
+	}
+
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return !isBuiltinType(t.Name)
+	case *ast.SelectorExpr:
+		return true
+	default:
+		return false
+	}
+}
+
+// isSupportedValueType allows strings, maps and named types, which are expected to be structs serialisable as JSON.
+// cmder validates the kind of named types when the binary builds its commands
+func isSupportedValueType(expr ast.Expr) bool {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name == "string" || !isBuiltinType(t.Name)
+	case *ast.SelectorExpr, *ast.MapType:
+		return true
+	default:
+		return false
+	}
+}
+
+func isBuiltinType(name string) bool {
+	obj := types.Universe.Lookup(name)
+	if obj == nil {
+		return false
+	}
+
+	_, ok := obj.(*types.TypeName)
+	return ok
+}
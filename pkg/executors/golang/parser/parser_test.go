@@ -0,0 +1,176 @@
+package parser
+
+import (
+	"context"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lunarway/shuttle/pkg/executors/golang/discover"
+)
+
+func TestParseFileNamespace(t *testing.T) {
+	file := path.Join(t.TempDir(), "migrate.go")
+	err := os.WriteFile(file, []byte(`package db
+
+import (
+	"context"
+
+	"github.com/lunarway/shuttle/pkg/sdk"
+)
+
+// Migrate applies all migrations
+func Migrate(ctx context.Context, sc sdk.ShuttleContext, steps int) error {
+	return nil
+}
+
+// Connect is a helper shared by the actions
+func Connect(dsn string) error {
+	return nil
+}
+
+type Client struct{}
+
+func (c *Client) Close(ctx context.Context) error {
+	return nil
+}
+`), 0o644)
+	require.NoError(t, err)
+
+	funcs, err := parseFile(file, nil, "db")
+
+	require.NoError(t, err)
+	require.Len(t, funcs, 1)
+	assert.Equal(t, "Migrate", funcs[0].Name)
+	assert.Equal(t, "db:migrate", funcs[0].CommandName())
+	assert.Equal(t, []Arg{{Name: "steps", Type: "int"}}, funcs[0].Input)
+}
+
+func TestParseFileNamespaceMain(t *testing.T) {
+	file := path.Join(t.TempDir(), "migrate.go")
+	err := os.WriteFile(file, []byte("package main\n"), 0o644)
+	require.NoError(t, err)
+
+	_, err = parseFile(file, nil, "db")
+
+	assert.ErrorContains(t, err, "package main can't be imported")
+}
+
+func TestParseFile(t *testing.T) {
+	src := `package main
+
+import "context"
+
+// Build builds the service
+func Build(ctx context.Context, tag string) error {
+	return nil
+}
+
+func helper(ctx context.Context) string {
+	return ""
+}
+
+type Client struct{}
+
+func (c *Client) Close(ctx context.Context) int {
+	return 0
+}
+
+func Version(ctx context.Context) (string, error) {
+	return "", nil
+}
+
+func Image(ctx context.Context) (*Image, error) {
+	return nil, nil
+}
+
+func Labels(ctx context.Context) (map[string]string, error) {
+	return nil, nil
+}
+`
+
+	funcs, err := parseFile("actions/build.go", src, "")
+
+	require.NoError(t, err)
+	require.Len(t, funcs, 4)
+	assert.Equal(t, "Build", funcs[0].Name)
+	assert.Equal(t, "Build builds the service", funcs[0].Description)
+	assert.Equal(t, []Arg{{Name: "tag", Type: "string"}}, funcs[0].Input)
+	assert.Equal(t, Output{Error: true}, funcs[0].Output)
+	assert.Equal(t, Output{Error: true, Value: "string"}, funcs[1].Output)
+	assert.Equal(t, Output{Error: true, Value: "*Image"}, funcs[2].Output)
+	assert.Equal(t, Output{Error: true, Value: "map[string]string"}, funcs[3].Output)
+}
+
+func TestParseFileErrors(t *testing.T) {
+	src := `package main
+
+import "context"
+
+func Build(ctx context.Context) string {
+	return ""
+}
+
+func Deploy(ctx context.Context, replicas complex64, env string) (int, error) {
+	return 0, nil
+}
+
+func Tag(ctx context.Context) (string, string, error) {
+	return "", "", nil
+}
+
+func Test(ctx context.Context) error {
+	return nil
+}
+
+func Lint(path string) error {
+	return nil
+}
+`
+
+	funcs, err := parseFile("actions/build.go", src, "")
+
+	require.Error(t, err)
+	assert.Equal(t, `actions/build.go:5:33: Build: output was string, the last output param must be error
+actions/build.go:9:43: Deploy: parameter replicas has unsupported type complex64
+actions/build.go:9:67: Deploy: output value has unsupported type int, only strings, structs and maps are supported
+actions/build.go:13:31: Tag: only error or (T, error) is supported as output params, got (string, string, error)
+actions/build.go:21:10: Lint: first parameter must be a context.Context`, err.Error())
+
+	var parseErr *Error
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, "Build", parseErr.Function)
+	assert.Equal(t, 5, parseErr.Pos.Line)
+
+	// valid actions are returned along with the errors
+	require.Len(t, funcs, 1)
+	assert.Equal(t, "Test", funcs[0].Name)
+}
+
+func TestGenerateAstCollectsErrors(t *testing.T) {
+	shuttlelocaldir := t.TempDir()
+	writeTmpFile := func(name, content string) {
+		t.Helper()
+
+		file := path.Join(shuttlelocaldir, "tmp", name)
+		require.NoError(t, os.MkdirAll(path.Dir(file), 0o755))
+		require.NoError(t, os.WriteFile(file, []byte(content), 0o644))
+	}
+	writeTmpFile("build.go", "package main\n\nfunc Build() string {\n\treturn \"\"\n}\n")
+	writeTmpFile("db/migrate.go", "package db\n\nimport \"context\"\n\nfunc Migrate(ctx context.Context, n uint8) error {\n\treturn nil\n}\n")
+
+	_, err := GenerateAst(context.Background(), shuttlelocaldir, &discover.ActionsDiscovered{
+		DirPath: "/project/actions",
+		Files:   []string{"build.go"},
+		Packages: []discover.ActionsPackage{
+			{Dir: "db", Files: []string{"migrate.go"}},
+		},
+	})
+
+	assert.EqualError(t, err, `/project/actions/build.go:3:11: Build: first parameter must be a context.Context
+/project/actions/build.go:3:14: Build: output was string, the last output param must be error
+/project/actions/db/migrate.go:5:37: Migrate: parameter n has unsupported type uint8`)
+}
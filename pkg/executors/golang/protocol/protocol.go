@@ -0,0 +1,190 @@
+// Package protocol defines how shuttle and golang actions binaries talk to each other.
+//
+// Shuttle inquires a binary with `lsjson`, which responds with a Handshake and the metadata of its actions. Binaries
+// built before the protocol was versioned don't include the handshake, and are treated as Legacy.
+//
+// When the binary supports CapabilityEvents, shuttle passes a pipe to the binary in the file descriptor named by
+// EventsFDEnv. The binary writes Events to it as JSON lines, while stdout and stderr are left to the actions
+// themselves.
+package protocol
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	// Legacy is the version of binaries built before the protocol was versioned. They only report their actions
+	Legacy = 0
+
+	// Version is the version spoken by this shuttle. Bump it on breaking changes, capabilities are used for additions
+	Version = 1
+
+	// MinVersion is the oldest version this shuttle is able to run
+	MinVersion = Legacy
+)
+
+// Capabilities of a binary announced in its Handshake
+const (
+	// CapabilityEvents is support for writing Events to the file descriptor in EventsFDEnv
+	CapabilityEvents = "events"
+)
+
+// EventsFDEnv is the file descriptor events are written to by the binary. It is only set if shuttle reads events
+const EventsFDEnv = "SHUTTLE_EVENTS_FD"
+
+// ErrIncompatible is returned when a binary speaks a protocol version shuttle is unable to run
+var ErrIncompatible = errors.New("incompatible actions protocol")
+
+// Handshake is the part of the lsjson response describing the protocol spoken by the binary
+type Handshake struct {
+	ProtocolVersion int      `json:"protocolVersion,omitempty"`
+	Capabilities    []string `json:"capabilities,omitempty"`
+}
+
+// NewHandshake is the handshake of binaries built with this version of shuttle
+func NewHandshake() Handshake {
+	return Handshake{
+		ProtocolVersion: Version,
+		Capabilities:    []string{CapabilityEvents},
+	}
+}
+
+// Has reports whether the binary announced capability
+func (h Handshake) Has(capability string) bool {
+	for _, c := range h.Capabilities {
+		if c == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// Check fails with ErrIncompatible if shuttle is unable to run a binary speaking the protocol of h
+func (h Handshake) Check() error {
+	if h.ProtocolVersion > Version {
+		return fmt.Errorf(
+			"%w: the actions binary speaks protocol v%d, but this shuttle only supports up to v%d. Upgrade shuttle, or align the shuttle version required by the actions go.mod",
+			ErrIncompatible,
+			h.ProtocolVersion,
+			Version,
+		)
+	}
+	if h.ProtocolVersion < MinVersion {
+		return fmt.Errorf(
+			"%w: the actions binary speaks protocol v%d, but this shuttle requires at least v%d. Rebuild the actions with a newer shuttle",
+			ErrIncompatible,
+			h.ProtocolVersion,
+			MinVersion,
+		)
+	}
+	return nil
+}
+
+// EventType is the kind of an Event
+type EventType string
+
+const (
+	EventLog      EventType = "log"
+	EventProgress EventType = "progress"
+	EventResult   EventType = "result"
+)
+
+// Level is the severity of a log event
+type Level string
+
+const (
+	LevelDebug Level = "debug"
+	LevelInfo  Level = "info"
+	LevelWarn  Level = "warn"
+	LevelError Level = "error"
+)
+
+// Event is a single JSON line written by the binary while running an action. Only the field matching Type is set
+type Event struct {
+	Type     EventType `json:"type"`
+	Time     time.Time `json:"time"`
+	Level    Level     `json:"level,omitempty"`
+	Message  string    `json:"message,omitempty"`
+	Progress *Progress `json:"progress,omitempty"`
+	Result   *Result   `json:"result,omitempty"`
+}
+
+// Progress reports how far an action is. Total is 0 if unknown
+type Progress struct {
+	Step    string `json:"step"`
+	Current int    `json:"current,omitempty"`
+	Total   int    `json:"total,omitempty"`
+}
+
+// Result is the last event of an action
+type Result struct {
+	Action     string `json:"action"`
+	Success    bool   `json:"success"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"durationMs"`
+	// Value is the JSON of the value returned by actions with a (T, error) signature
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Encoder writes events as JSON lines. It is safe for concurrent use, as actions may report from several goroutines
+type Encoder struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{
+		enc: json.NewEncoder(w),
+	}
+}
+
+func (e *Encoder) Encode(event Event) error {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.enc.Encode(event)
+}
+
+// Decoder reads events written by an Encoder
+type Decoder struct {
+	scanner *bufio.Scanner
+}
+
+func NewDecoder(r io.Reader) *Decoder {
+	scanner := bufio.NewScanner(r)
+	// support large log messages
+	scanner.Buffer(make([]byte, 0, 64*1024), 512e3)
+
+	return &Decoder{
+		scanner: scanner,
+	}
+}
+
+// Decode returns the next event, or io.EOF when the binary closed the stream
+func (d *Decoder) Decode() (Event, error) {
+	for d.scanner.Scan() {
+		line := d.scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var event Event
+		if err := json.Unmarshal(line, &event); err != nil {
+			return Event{}, fmt.Errorf("invalid actions event '%s': %w", line, err)
+		}
+		return event, nil
+	}
+	if err := d.scanner.Err(); err != nil {
+		return Event{}, err
+	}
+	return Event{}, io.EOF
+}
@@ -0,0 +1,65 @@
+package protocol
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandshakeCheck(t *testing.T) {
+	t.Run("current version", func(t *testing.T) {
+		assert.NoError(t, NewHandshake().Check())
+	})
+
+	t.Run("legacy binaries without a handshake", func(t *testing.T) {
+		assert.NoError(t, Handshake{}.Check())
+	})
+
+	t.Run("newer version", func(t *testing.T) {
+		err := Handshake{ProtocolVersion: Version + 1}.Check()
+
+		assert.ErrorIs(t, err, ErrIncompatible)
+		assert.ErrorContains(t, err, "only supports up to v1")
+	})
+}
+
+func TestHandshakeHas(t *testing.T) {
+	assert.True(t, NewHandshake().Has(CapabilityEvents))
+	assert.False(t, Handshake{}.Has(CapabilityEvents))
+}
+
+func TestEncodeDecode(t *testing.T) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, enc.Encode(Event{Type: EventLog, Time: now, Level: LevelWarn, Message: "careful"}))
+	require.NoError(t, enc.Encode(Event{Type: EventResult, Result: &Result{Action: "build", Success: true}}))
+	buf.WriteString("\n")
+
+	dec := NewDecoder(&buf)
+
+	event, err := dec.Decode()
+	require.NoError(t, err)
+	assert.Equal(t, Event{Type: EventLog, Time: now, Level: LevelWarn, Message: "careful"}, event)
+
+	event, err = dec.Decode()
+	require.NoError(t, err)
+	assert.Equal(t, EventResult, event.Type)
+	assert.False(t, event.Time.IsZero(), "time is set by the encoder")
+	assert.Equal(t, &Result{Action: "build", Success: true}, event.Result)
+
+	_, err = dec.Decode()
+	assert.True(t, errors.Is(err, io.EOF))
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	_, err := NewDecoder(bytes.NewBufferString("not json\n")).Decode()
+
+	assert.ErrorContains(t, err, "invalid actions event 'not json'")
+}
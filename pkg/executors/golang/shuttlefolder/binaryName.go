@@ -3,7 +3,11 @@ package shuttlefolder
 import (
 	"encoding/hex"
 	"fmt"
+	"io/fs"
+	"os"
 	"path"
+	"sort"
+	"strings"
 )
 
 const (
@@ -14,7 +18,55 @@ const (
 func CalculateBinaryPath(shuttledir, hash string) string {
 	return path.Join(
 		shuttledir,
-		"binaries",
+		TaskBinaryDir,
 		fmt.Sprintf("%s-%s", TaskBinaryPrefix, hex.EncodeToString([]byte(hash)[:16])),
 	)
 }
+
+// PruneBinaries removes all but the keep most recently used binaries in shuttledir
+func PruneBinaries(shuttledir string, keep int) error {
+	binarydir := path.Join(shuttledir, TaskBinaryDir)
+	entries, err := os.ReadDir(binarydir)
+	if err != nil {
+		return err
+	}
+
+	binaries := make([]fs.FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), TaskBinaryPrefix+"-") {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		binaries = append(binaries, info)
+	}
+	if len(binaries) <= keep {
+		return nil
+	}
+
+	sort.Slice(binaries, func(i, j int) bool {
+		return binaries[i].ModTime().After(binaries[j].ModTime())
+	})
+	for _, binary := range binaries[keep:] {
+		if err := os.Remove(path.Join(binarydir, binary.Name())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DistDir is the directory of the actions module holding prebuilt binaries
+const DistDir = "dist"
+
+// PrebuiltBinaryPath is the path of the binary prebuilt from the sources with hash for goos and goarch, i.e.
+// actions/dist/actions-<hash>-linux-amd64
+func PrebuiltBinaryPath(actionsDir, hash, goos, goarch string) string {
+	return path.Join(
+		actionsDir,
+		DistDir,
+		fmt.Sprintf("%s-%s-%s-%s", TaskBinaryPrefix, hex.EncodeToString([]byte(hash)[:16]), goos, goarch),
+	)
+}
@@ -0,0 +1,46 @@
+package shuttlefolder_test
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/lunarway/shuttle/pkg/executors/golang/shuttlefolder"
+)
+
+func TestPruneBinaries(t *testing.T) {
+	shuttledir := t.TempDir()
+	binarydir := path.Join(shuttledir, shuttlefolder.TaskBinaryDir)
+	require.NoError(t, os.MkdirAll(binarydir, 0o755))
+
+	now := time.Now()
+	for i, name := range []string{"actions-a", "actions-b", "actions-c", "other"} {
+		file := path.Join(binarydir, name)
+		require.NoError(t, os.WriteFile(file, nil, 0o755))
+		modTime := now.Add(-time.Duration(i) * time.Hour)
+		require.NoError(t, os.Chtimes(file, modTime, modTime))
+	}
+
+	err := shuttlefolder.PruneBinaries(shuttledir, 2)
+
+	require.NoError(t, err)
+	entries, err := os.ReadDir(binarydir)
+	require.NoError(t, err)
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.Equal(t, []string{"actions-a", "actions-b", "other"}, names)
+}
+
+func TestPrebuiltBinaryPath(t *testing.T) {
+	hash := "h1:47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="
+
+	binaryPath := shuttlefolder.PrebuiltBinaryPath("project/actions", hash, "darwin", "arm64")
+
+	assert.Equal(t, "project/actions/dist/actions-68313a3437444551706a38484253612b-darwin-arm64", binaryPath)
+}
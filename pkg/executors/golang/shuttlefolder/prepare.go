@@ -15,8 +15,14 @@ func CopyFiles(
 	actions *discover.ActionsDiscovered,
 ) error {
 	tmpdir := path.Join(shuttlelocaldir, "tmp")
-
-	return cp.Copy(actions.DirPath, tmpdir)
+	distdir := path.Join(actions.DirPath, DistDir)
+
+	return cp.Copy(actions.DirPath, tmpdir, cp.Options{
+		// prebuilt binaries aren't needed to build the actions
+		Skip: func(srcinfo os.FileInfo, src, dest string) (bool, error) {
+			return src == distdir, nil
+		},
+	})
 }
 
 func Move(src, dest string) error {
@@ -28,10 +34,8 @@ func GenerateTmpDir(ctx context.Context, shuttlelocaldir string) error {
 		return err
 	}
 
+	// previously built binaries are kept, see PruneBinaries
 	binarydir := path.Join(shuttlelocaldir, "binaries")
-	if err := os.RemoveAll(binarydir); err != nil {
-		return nil
-	}
 	if err := os.MkdirAll(binarydir, 0o755); err != nil {
 		return err
 	}
@@ -111,6 +111,7 @@ func setupCommandEnvironmentVariables(execCmd *cmd.Cmd, context ActionExecutionC
 	for name, value := range context.ScriptContext.Args {
 		execCmd.Env = append(execCmd.Env, fmt.Sprintf("%s=%s", name, value))
 	}
+	execCmd.Env = append(execCmd.Env, outputEnv(context.ScriptContext)...)
 	execCmd.Env = append(
 		execCmd.Env,
 		fmt.Sprintf("plan=%s", context.ScriptContext.Project.LocalPlanPath),
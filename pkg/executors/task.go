@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 
 	"github.com/go-cmd/cmd"
 	"github.com/lunarway/shuttle/pkg/config"
 	"github.com/lunarway/shuttle/pkg/executors/golang/executer"
+	"github.com/lunarway/shuttle/pkg/sdk"
 	"github.com/lunarway/shuttle/pkg/ui"
 )
 
@@ -19,21 +21,58 @@ func TaskExecutor(action config.ShuttleAction) (Executor, bool) {
 func executeTask(ctx context.Context, ui *ui.UI, context ActionExecutionContext) error {
 	context.ScriptContext.Project.UI.Verboseln("Starting task command: %s", context.Action.Task)
 
-	args := make([]string, 0)
-	args = append(args, context.Action.Task)
-	for name, value := range context.ScriptContext.Args {
-		args = append(args, fmt.Sprintf("--%s", name))
-		args = append(args, value)
-	}
-
-	err := executer.Run(ctx, ui, &context.ScriptContext.Project, fmt.Sprintf("%s/shuttle.yaml", context.ScriptContext.Project.ProjectPath), args...)
+	value, err := executer.RunWithValue(
+		ctx,
+		ui,
+		&context.ScriptContext.Project,
+		fmt.Sprintf("%s/shuttle.yaml", context.ScriptContext.Project.ProjectPath),
+		outputEnv(context.ScriptContext),
+		taskArgs(context)...,
+	)
 	if err != nil {
 		return err
 	}
 
+	// later actions of the script read the value from their environment, see sdk.Output
+	if value != nil && context.ScriptContext.Outputs != nil {
+		context.ScriptContext.Outputs[context.Action.Task] = value
+	}
+
 	return nil
 }
 
+// taskArgs returns the name of the action followed by the args of the script as flags, sorted by name
+func taskArgs(context ActionExecutionContext) []string {
+	names := make([]string, 0, len(context.ScriptContext.Args))
+	for name := range context.ScriptContext.Args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	args := []string{context.Action.Task}
+	for _, name := range names {
+		value := context.ScriptContext.Args[name]
+		// empty args which weren't provided are left out, as typed flags can't parse an empty value. An explicitly empty
+		// value is passed on, so it doesn't fall back to the default of the action
+		if value == "" && !context.ScriptContext.ProvidedArgs[name] {
+			continue
+		}
+		// --name=value is required for bool flags, as they don't consume the next argument
+		args = append(args, fmt.Sprintf("--%s=%s", name, value))
+	}
+
+	return args
+}
+
+// outputEnv passes the values returned by earlier golang actions of the script as environment variables
+func outputEnv(scriptContext ScriptExecutionContext) []string {
+	env := make([]string, 0, len(scriptContext.Outputs))
+	for action, value := range scriptContext.Outputs {
+		env = append(env, fmt.Sprintf("%s=%s", sdk.OutputEnv(action), sdk.OutputEnvValue(value)))
+	}
+	return env
+}
+
 func setupTaskCommandEnvironmentVariables(execCmd *cmd.Cmd, context ActionExecutionContext) {
 	shuttlePath, _ := filepath.Abs(filepath.Dir(os.Args[0]))
 
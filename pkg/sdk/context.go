@@ -1,8 +1,11 @@
 package sdk
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io/ioutil"
+	"os"
 	"path"
 
 	"github.com/lunarway/shuttle/pkg/config"
@@ -15,6 +18,8 @@ type ShuttleContext struct {
 	LocalPlanPath             string             `yaml:"-"`
 	LocalShuttleDirectoryPath string             `yaml:"-"`
 	TempDirectoryPath         string             `yaml:"-"`
+	// StrictTemplates is the default of the plan for failing templates on missing vars, see WithStrict
+	StrictTemplates bool `yaml:"-"`
 }
 
 func LoadShuttleContext(projectPath, localPlanPath string) (ShuttleContext, error) {
@@ -39,6 +44,93 @@ func LoadShuttleContext(projectPath, localPlanPath string) (ShuttleContext, erro
 	return result, nil
 }
 
+// ShuttleContextFileEnv points golang actions to a yaml file with the ShuttleContext of the shuttle running them
+const ShuttleContextFileEnv = "SHUTTLE_CONTEXT_FILE"
+
+// shuttleContextFile is the format of the file pointed to by ShuttleContextFileEnv
+type shuttleContextFile struct {
+	Variables                 config.DynamicYaml `yaml:"vars"`
+	ProjectPath               string             `yaml:"projectPath"`
+	LocalPlanPath             string             `yaml:"localPlanPath"`
+	LocalShuttleDirectoryPath string             `yaml:"localShuttleDirectoryPath"`
+	TempDirectoryPath         string             `yaml:"tempDirectoryPath"`
+	StrictTemplates           bool               `yaml:"strictTemplates"`
+}
+
+// NewShuttleContext returns the ShuttleContext of a project loaded by shuttle, so actions see the same configuration as
+// yaml scripts
+func NewShuttleContext(c *config.ShuttleProjectContext) ShuttleContext {
+	return ShuttleContext{
+		Variables:                 c.Config.Variables,
+		ProjectPath:               c.ProjectPath,
+		LocalPlanPath:             c.LocalPlanPath,
+		LocalShuttleDirectoryPath: c.LocalShuttleDirectoryPath,
+		TempDirectoryPath:         c.TempDirectoryPath,
+		StrictTemplates:           c.Plan.Templates.Strict,
+	}
+}
+
+// WriteShuttleContextFile writes sc to file in the format read by ShuttleContextFromEnv
+func WriteShuttleContextFile(file string, sc ShuttleContext) error {
+	content, err := yaml.Marshal(shuttleContextFile{
+		Variables:                 sc.Variables,
+		ProjectPath:               sc.ProjectPath,
+		LocalPlanPath:             sc.LocalPlanPath,
+		LocalShuttleDirectoryPath: sc.LocalShuttleDirectoryPath,
+		TempDirectoryPath:         sc.TempDirectoryPath,
+		StrictTemplates:           sc.StrictTemplates,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal shuttle context: %w", err)
+	}
+
+	return os.WriteFile(file, content, 0o600)
+}
+
+// ShuttleContextFromEnv reads the ShuttleContext passed by shuttle to a golang action
+func ShuttleContextFromEnv() (ShuttleContext, error) {
+	file := os.Getenv(ShuttleContextFileEnv)
+	if file == "" {
+		return ShuttleContext{}, fmt.Errorf("%s is not set, golang actions have to be run by shuttle", ShuttleContextFileEnv)
+	}
+
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return ShuttleContext{}, fmt.Errorf("failed to read shuttle context: %w", err)
+	}
+
+	var contextFile shuttleContextFile
+	if err := yaml.Unmarshal(content, &contextFile); err != nil {
+		return ShuttleContext{}, fmt.Errorf("failed to parse shuttle context %s: %w", file, err)
+	}
+
+	return ShuttleContext{
+		Variables:                 contextFile.Variables,
+		ProjectPath:               contextFile.ProjectPath,
+		LocalPlanPath:             contextFile.LocalPlanPath,
+		LocalShuttleDirectoryPath: contextFile.LocalShuttleDirectoryPath,
+		TempDirectoryPath:         contextFile.TempDirectoryPath,
+		StrictTemplates:           contextFile.StrictTemplates,
+	}, nil
+}
+
+type shuttleContextKey struct{}
+
+// WithShuttleContext returns a copy of ctx carrying sc
+func WithShuttleContext(ctx context.Context, sc ShuttleContext) context.Context {
+	return context.WithValue(ctx, shuttleContextKey{}, sc)
+}
+
+// ShuttleContextFrom returns the ShuttleContext passed to a golang action through its context.Context
+func ShuttleContextFrom(ctx context.Context) (ShuttleContext, error) {
+	sc, ok := ctx.Value(shuttleContextKey{}).(ShuttleContext)
+	if !ok {
+		return ShuttleContext{}, errors.New("context does not carry a shuttle context")
+	}
+
+	return sc, nil
+}
+
 func LoadShuttleYaml(projectPath string) ([]byte, error) {
 	file, err := ioutil.ReadFile(path.Join(projectPath, "shuttle.yaml"))
 	if err != nil {
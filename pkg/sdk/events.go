@@ -0,0 +1,83 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/lunarway/shuttle/pkg/executors/golang/protocol"
+)
+
+type eventsKey struct{}
+
+// WithEvents returns a copy of ctx reporting logs and progress of golang actions to shuttle through enc
+func WithEvents(ctx context.Context, enc *protocol.Encoder) context.Context {
+	return context.WithValue(ctx, eventsKey{}, enc)
+}
+
+// EventsFrom returns the encoder of events set by WithEvents
+func EventsFrom(ctx context.Context) (*protocol.Encoder, bool) {
+	enc, ok := ctx.Value(eventsKey{}).(*protocol.Encoder)
+	return enc, ok && enc != nil
+}
+
+// Debugf logs a message shown by shuttle in verbose mode
+func Debugf(ctx context.Context, format string, args ...any) {
+	logf(ctx, protocol.LevelDebug, format, args...)
+}
+
+// Infof logs a message
+func Infof(ctx context.Context, format string, args ...any) {
+	logf(ctx, protocol.LevelInfo, format, args...)
+}
+
+// Warnf logs a warning
+func Warnf(ctx context.Context, format string, args ...any) {
+	logf(ctx, protocol.LevelWarn, format, args...)
+}
+
+// Errorf logs an error, without failing the action
+func Errorf(ctx context.Context, format string, args ...any) {
+	logf(ctx, protocol.LevelError, format, args...)
+}
+
+// Progress reports that the action reached step, i.e. 2 out of total 5. total is 0 if unknown
+func Progress(ctx context.Context, step string, current, total int) {
+	enc, ok := EventsFrom(ctx)
+	if !ok {
+		if total > 0 {
+			fmt.Fprintf(os.Stderr, "[%d/%d] %s\n", current, total, step)
+		} else {
+			fmt.Fprintln(os.Stderr, step)
+		}
+		return
+	}
+
+	_ = enc.Encode(protocol.Event{
+		Type: protocol.EventProgress,
+		Progress: &protocol.Progress{
+			Step:    step,
+			Current: current,
+			Total:   total,
+		},
+	})
+}
+
+// logf falls back to stderr when the action isn't run by a shuttle reading events
+func logf(ctx context.Context, level protocol.Level, format string, args ...any) {
+	message := fmt.Sprintf(format, args...)
+
+	enc, ok := EventsFrom(ctx)
+	if !ok {
+		if level != protocol.LevelDebug {
+			fmt.Fprintln(os.Stderr, message)
+		}
+		return
+	}
+
+	_ = enc.Encode(protocol.Event{
+		Type:    protocol.EventLog,
+		Level:   level,
+		Message: message,
+	})
+}
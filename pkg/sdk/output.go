@@ -0,0 +1,63 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// OutputEnvPrefix prefixes the environment variables holding the values returned by earlier golang actions of a
+// script
+const OutputEnvPrefix = "SHUTTLE_OUTPUT_"
+
+// OutputEnv is the environment variable holding the value returned by action, i.e. SHUTTLE_OUTPUT_DB_MIGRATE for
+// db:migrate
+func OutputEnv(action string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, action)
+
+	return OutputEnvPrefix + name
+}
+
+// OutputEnvValue formats the JSON value returned by an action for its OutputEnv. Strings are unquoted so they are
+// usable as is in shell actions, other values are kept as JSON
+func OutputEnvValue(value json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(value, &s); err == nil {
+		return s
+	}
+
+	return string(value)
+}
+
+// Output reads the value returned by action earlier in the same script into v, i.e.
+//
+//	var version string
+//	err := sdk.Output("version", &version)
+func Output(action string, v any) error {
+	raw, ok := os.LookupEnv(OutputEnv(action))
+	if !ok {
+		return fmt.Errorf("no value returned by action %s earlier in the script", action)
+	}
+
+	// strings are passed unquoted, see OutputEnvValue
+	if s, ok := v.(*string); ok {
+		*s = raw
+		return nil
+	}
+
+	if err := json.Unmarshal([]byte(raw), v); err != nil {
+		return fmt.Errorf("failed to read value returned by action %s: %w", action, err)
+	}
+
+	return nil
+}
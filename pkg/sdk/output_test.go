@@ -0,0 +1,32 @@
+package sdk
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutputEnv(t *testing.T) {
+	assert.Equal(t, "SHUTTLE_OUTPUT_VERSION", OutputEnv("version"))
+	assert.Equal(t, "SHUTTLE_OUTPUT_DB_MIGRATE", OutputEnv("db:migrate"))
+	assert.Equal(t, "SHUTTLE_OUTPUT_CHANGED_SERVICES", OutputEnv("changed-services"))
+}
+
+func TestOutput(t *testing.T) {
+	t.Setenv(OutputEnv("version"), OutputEnvValue(json.RawMessage(`"v1.2.3"`)))
+	t.Setenv(OutputEnv("image"), OutputEnvValue(json.RawMessage(`{"name":"shuttle"}`)))
+
+	var version string
+	require.NoError(t, Output("version", &version))
+	assert.Equal(t, "v1.2.3", version)
+
+	var image struct {
+		Name string `json:"name"`
+	}
+	require.NoError(t, Output("image", &image))
+	assert.Equal(t, "shuttle", image.Name)
+
+	assert.ErrorContains(t, Output("missing", &version), "no value returned by action missing")
+}
@@ -7,6 +7,7 @@ import (
 	"path"
 	"text/template"
 
+	"github.com/lunarway/shuttle/pkg/config"
 	"github.com/lunarway/shuttle/pkg/templates"
 	"github.com/pkg/errors"
 )
@@ -18,6 +19,24 @@ type TemplateContext struct {
 	ProjectPath string
 }
 
+// NewTemplateContext returns the TemplateContext of sc with values merged over its vars, like shuttle template with
+// --values and --set, i.e.
+//
+//	context, err := sdk.NewTemplateContext(sc, nil, config.Values{Files: []string{"values-prod.yaml"}})
+func NewTemplateContext(sc ShuttleContext, args map[string]string, values config.Values) (TemplateContext, error) {
+	vars, err := values.Apply(sc.Variables)
+	if err != nil {
+		return TemplateContext{}, err
+	}
+
+	return TemplateContext{
+		Vars:        vars,
+		Args:        args,
+		PlanPath:    sc.LocalPlanPath,
+		ProjectPath: sc.ProjectPath,
+	}, nil
+}
+
 func resolveFirstPath(paths []string) string {
 	for _, templatePath := range paths {
 		if fileAvailable(templatePath) {
@@ -49,17 +68,39 @@ func ResolveTemplatePath(project ShuttleContext, templateName string) (string, e
 	return templatePath, nil
 }
 
+// TemplateOption configures Generate
+type TemplateOption func(*templateOptions)
+
+type templateOptions struct {
+	strict bool
+}
+
+// WithStrict fails rendering on missing vars, i.e. with the plan default
+//
+//	sdk.Generate(templatePath, templateName, output, context, "{{", "}}", sdk.WithStrict(sc.StrictTemplates))
+func WithStrict(strict bool) TemplateOption {
+	return func(o *templateOptions) {
+		o.strict = strict
+	}
+}
+
 func Generate(
 	templatePath, templateName, outputFilepath string,
 	context TemplateContext,
 	leftDelim, rightDelim string,
+	opts ...TemplateOption,
 ) error {
 	file, err := os.Create(outputFilepath)
 	if err != nil {
 		return errors.WithMessagef(err, "create template output file '%s'", outputFilepath)
 	}
 
-	err = renderTemplate(templatePath, templateName, file, context, leftDelim, rightDelim)
+	var o templateOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	err = renderTemplate(templatePath, templateName, file, context, leftDelim, rightDelim, o.strict)
 	if err != nil {
 		return err
 	}
@@ -72,11 +113,16 @@ func renderTemplate(
 	output io.Writer,
 	context TemplateContext,
 	leftDelim, rightDelim string,
+	strict bool,
 ) error {
-	tmpl, err := template.New(templateName).
+	tmpl := template.New(templateName).
 		Delims(leftDelim, rightDelim).
-		Funcs(templates.GetFuncMap()).
-		ParseFiles(templatePath)
+		Funcs(templates.GetFuncMap())
+	if strict {
+		tmpl = tmpl.Option("missingkey=error").
+			Funcs(templates.GetStrictFuncMapOf(context.Vars))
+	}
+	tmpl, err := tmpl.ParseFiles(templatePath)
 	if err != nil {
 		return err
 	}
@@ -6,6 +6,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/lunarway/shuttle/pkg/config"
 )
 
 func TestRenderTemplate(t *testing.T) {
@@ -14,6 +16,7 @@ func TestRenderTemplate(t *testing.T) {
 		templatePath string
 		output       string
 		templateCtx  TemplateContext
+		strict       bool
 		err          error
 	}{
 		{
@@ -52,6 +55,40 @@ func TestRenderTemplate(t *testing.T) {
 			output: `bar: baz
 foo: {{ get "bar" .Vars }}
 
+`,
+			err: nil,
+		},
+		{
+			name:         "unknown value",
+			templatePath: "testdata/get_unknown_value.yaml",
+			templateCtx: TemplateContext{
+				Vars: map[string]interface{}{},
+			},
+			output: `foo: <no value>
+`,
+			err: nil,
+		},
+		{
+			name:         "unknown value in strict mode",
+			templatePath: "testdata/get_unknown_value.yaml",
+			templateCtx: TemplateContext{
+				Vars: map[string]interface{}{},
+			},
+			strict: true,
+			err: errors.New(
+				"template: get_unknown_value.yaml:1:8: executing \"get_unknown_value.yaml\" at <get \"unknown.bar\" .Vars>: error calling get: var unknown.bar is missing",
+			),
+		},
+		{
+			name:         "valid in strict mode",
+			templatePath: "testdata/valid.yaml",
+			templateCtx: TemplateContext{
+				Vars: map[string]interface{}{
+					"bar": "bar",
+				},
+			},
+			strict: true,
+			output: `foo: bar
 `,
 			err: nil,
 		},
@@ -59,7 +96,7 @@ foo: {{ get "bar" .Vars }}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
 			var output bytes.Buffer
-			err := renderTemplate(tc.templatePath, tc.name, &output, tc.templateCtx, "{{", "}}")
+			err := renderTemplate(tc.templatePath, tc.name, &output, tc.templateCtx, "{{", "}}", tc.strict)
 
 			if tc.err != nil {
 				assert.EqualError(t, err, tc.err.Error())
@@ -71,3 +108,21 @@ foo: {{ get "bar" .Vars }}
 		})
 	}
 }
+
+func TestNewTemplateContext(t *testing.T) {
+	sc := ShuttleContext{
+		Variables:     config.DynamicYaml{"env": "dev", "replicas": 1},
+		ProjectPath:   "/project",
+		LocalPlanPath: "/project/.shuttle/plan",
+	}
+
+	context, err := NewTemplateContext(sc, map[string]string{"tag": "v1"}, config.Values{Set: []string{"env=prod"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, TemplateContext{
+		Vars:        config.DynamicYaml{"env": "prod", "replicas": 1},
+		Args:        map[string]string{"tag": "v1"},
+		PlanPath:    "/project/.shuttle/plan",
+		ProjectPath: "/project",
+	}, context)
+}
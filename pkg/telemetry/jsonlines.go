@@ -19,18 +19,19 @@ type JsonLinesTelemetryClient struct {
 	*http.Client
 	logLocation string
 	writeMutex  sync.Mutex
+	redaction   *RedactionPolicy
 }
 
 func (t *JsonLinesTelemetryClient) Trace(
 	ctx context.Context,
 	properties map[string]string,
 ) {
-	copyHostMap(t.properties, properties)
+	properties = copyHostMap(t.properties, properties)
 
 	event := &UploadTraceEvent{
 		App:        appKey,
 		Timestamp:  time.Now().UTC(),
-		Properties: includeContext(ctx, properties),
+		Properties: t.redaction.Redact(includeContext(ctx, properties)),
 	}
 
 	content, err := json.Marshal(event)
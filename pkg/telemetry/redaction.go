@@ -0,0 +1,199 @@
+package telemetry
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	envRedactionPolicy = "SHUTTLE_TELEMETRY_REDACTION_POLICY"
+
+	telemetryHostname = "system.hostname"
+	redactedValue     = "[REDACTED]"
+	hashPrefix        = "sha256(16)="
+)
+
+// Hostname handling modes of a RedactionConfig
+const (
+	HostnameKeep = "keep"
+	HostnameHash = "hash"
+	HostnameDrop = "drop"
+)
+
+// RedactionConfig describes which telemetry properties are allowed to leave the machine.
+//
+// Keys are matched with path.Match, so `system.*` matches all system properties
+type RedactionConfig struct {
+	// AllowKeys keeps only properties matching one of the patterns. All keys are allowed if empty
+	AllowKeys []string `yaml:"allowKeys"`
+	// DenyKeys drops properties matching one of the patterns. Deny takes precedence over allow
+	DenyKeys []string `yaml:"denyKeys"`
+	// ErrorPatterns are regular expressions replaced by [REDACTED] in error properties
+	ErrorPatterns []string `yaml:"errorPatterns"`
+	// Hostname is either keep, hash or drop. Defaults to hash
+	Hostname string `yaml:"hostname"`
+}
+
+// RedactionPolicy is applied to trace event properties before they are written and uploaded.
+// A nil policy only hashes the hostname, see defaultRedactionPolicy
+type RedactionPolicy struct {
+	config        RedactionConfig
+	errorPatterns []*regexp.Regexp
+}
+
+// defaultRedactionPolicy is used when no policy is set. The hostname identifies the machine, so it is only kept when a
+// policy opts in with hostname: keep
+var defaultRedactionPolicy = &RedactionPolicy{
+	config: RedactionConfig{Hostname: HostnameHash},
+}
+
+func NewRedactionPolicy(config RedactionConfig) (*RedactionPolicy, error) {
+	switch config.Hostname {
+	case "":
+		config.Hostname = HostnameHash
+	case HostnameKeep, HostnameHash, HostnameDrop:
+	default:
+		return nil, fmt.Errorf(
+			"unknown hostname redaction '%s', must be one of: %s, %s, %s",
+			config.Hostname,
+			HostnameKeep,
+			HostnameHash,
+			HostnameDrop,
+		)
+	}
+
+	for _, pattern := range append(append([]string{}, config.AllowKeys...), config.DenyKeys...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid key pattern '%s': %w", pattern, err)
+		}
+	}
+
+	errorPatterns := make([]*regexp.Regexp, 0, len(config.ErrorPatterns))
+	for _, pattern := range config.ErrorPatterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid error pattern '%s': %w", pattern, err)
+		}
+		errorPatterns = append(errorPatterns, re)
+	}
+
+	return &RedactionPolicy{
+		config:        config,
+		errorPatterns: errorPatterns,
+	}, nil
+}
+
+// LoadRedactionPolicy reads a yaml formatted RedactionConfig from file
+func LoadRedactionPolicy(file string) (*RedactionPolicy, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read telemetry redaction policy: %w", err)
+	}
+
+	var config RedactionConfig
+	if err := yaml.UnmarshalStrict(content, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse telemetry redaction policy %s: %w", file, err)
+	}
+
+	return NewRedactionPolicy(config)
+}
+
+// RedactionPolicyFromEnv loads the policy pointed to by SHUTTLE_TELEMETRY_REDACTION_POLICY.
+// It returns nil if the variable isn't set
+func RedactionPolicyFromEnv() (*RedactionPolicy, error) {
+	file := os.Getenv(envRedactionPolicy)
+	if file == "" {
+		return nil, nil
+	}
+
+	return LoadRedactionPolicy(file)
+}
+
+// Redact returns a redacted copy of properties
+func (p *RedactionPolicy) Redact(properties map[string]string) map[string]string {
+	if p == nil {
+		p = defaultRedactionPolicy
+	}
+
+	redacted := make(map[string]string, len(properties))
+	for key, value := range properties {
+		if !p.keyAllowed(key) {
+			continue
+		}
+
+		if key == telemetryHostname {
+			switch p.config.Hostname {
+			case HostnameDrop:
+				continue
+			case HostnameHash:
+				// policies are applied when events are written and again when they are uploaded
+				if !strings.HasPrefix(value, hashPrefix) {
+					value = hashValue(value)
+				}
+			}
+		}
+
+		if isErrorKey(key) {
+			for _, re := range p.errorPatterns {
+				value = re.ReplaceAllString(value, redactedValue)
+			}
+		}
+
+		redacted[key] = value
+	}
+
+	return redacted
+}
+
+// RedactEvents returns redacted copies of events
+func (p *RedactionPolicy) RedactEvents(events []UploadTraceEvent) []UploadTraceEvent {
+	if p == nil {
+		p = defaultRedactionPolicy
+	}
+
+	redacted := make([]UploadTraceEvent, 0, len(events))
+	for _, event := range events {
+		event.Properties = p.Redact(event.Properties)
+		redacted = append(redacted, event)
+	}
+
+	return redacted
+}
+
+func (p *RedactionPolicy) keyAllowed(key string) bool {
+	if matchesAny(p.config.DenyKeys, key) {
+		return false
+	}
+	if len(p.config.AllowKeys) == 0 {
+		return true
+	}
+
+	return matchesAny(p.config.AllowKeys, key)
+}
+
+func matchesAny(patterns []string, key string) bool {
+	for _, pattern := range patterns {
+		// patterns are validated in NewRedactionPolicy
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isErrorKey(key string) bool {
+	return key == "error" || strings.HasSuffix(key, ".error")
+}
+
+func hashValue(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hashPrefix + hex.EncodeToString(sum[:])[0:16]
+}
@@ -0,0 +1,151 @@
+package telemetry
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactionPolicy(t *testing.T) {
+	t.Parallel()
+
+	properties := map[string]string{
+		"label":                  "build",
+		"error":                  "failed to login with token=abc123",
+		"system.goinfo.error":    "token=def456",
+		"system.hostname":        "my-laptop",
+		"system.os":              "linux",
+		"shuttle.command.args.x": "sha256(16)=abc",
+	}
+
+	t.Run("nil policy hashes hostname", func(t *testing.T) {
+		t.Parallel()
+
+		var policy *RedactionPolicy
+		expected := make(map[string]string, len(properties))
+		for key, value := range properties {
+			expected[key] = value
+		}
+		expected["system.hostname"] = hashValue("my-laptop")
+		assert.Equal(t, expected, policy.Redact(properties))
+	})
+
+	t.Run("hostname hashed by default", func(t *testing.T) {
+		t.Parallel()
+
+		policy, err := NewRedactionPolicy(RedactionConfig{AllowKeys: []string{"system.hostname"}})
+		require.NoError(t, err)
+
+		assert.Equal(t, map[string]string{
+			"system.hostname": hashValue("my-laptop"),
+		}, policy.Redact(properties))
+	})
+
+	t.Run("hostname hashed once", func(t *testing.T) {
+		t.Parallel()
+
+		var policy *RedactionPolicy
+		hashed := policy.Redact(map[string]string{"system.hostname": "my-laptop"})
+		assert.Equal(t, hashed, policy.Redact(hashed))
+	})
+
+	t.Run("keep hostname", func(t *testing.T) {
+		t.Parallel()
+
+		policy, err := NewRedactionPolicy(RedactionConfig{Hostname: HostnameKeep})
+		require.NoError(t, err)
+
+		assert.Equal(t, properties, policy.Redact(properties))
+	})
+
+	t.Run("deny keys and scrub errors", func(t *testing.T) {
+		t.Parallel()
+
+		policy, err := NewRedactionPolicy(RedactionConfig{
+			DenyKeys:      []string{"shuttle.command.args.*"},
+			ErrorPatterns: []string{`token=\S+`},
+			Hostname:      HostnameDrop,
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, map[string]string{
+			"label":               "build",
+			"error":               "failed to login with [REDACTED]",
+			"system.goinfo.error": "[REDACTED]",
+			"system.os":           "linux",
+		}, policy.Redact(properties))
+	})
+
+	t.Run("allow keys and hash hostname", func(t *testing.T) {
+		t.Parallel()
+
+		policy, err := NewRedactionPolicy(RedactionConfig{
+			AllowKeys: []string{"label", "system.*"},
+			DenyKeys:  []string{"system.goinfo.error"},
+			Hostname:  HostnameHash,
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, map[string]string{
+			"label":           "build",
+			"system.hostname": hashValue("my-laptop"),
+			"system.os":       "linux",
+		}, policy.Redact(properties))
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := NewRedactionPolicy(RedactionConfig{Hostname: "obfuscate"})
+		assert.Error(t, err)
+
+		_, err = NewRedactionPolicy(RedactionConfig{ErrorPatterns: []string{"("}})
+		assert.Error(t, err)
+
+		_, err = NewRedactionPolicy(RedactionConfig{DenyKeys: []string{"["}})
+		assert.Error(t, err)
+	})
+
+	t.Run("load from file", func(t *testing.T) {
+		t.Parallel()
+
+		file := path.Join(t.TempDir(), "policy.yaml")
+		err := os.WriteFile(file, []byte("hostname: drop\ndenyKeys:\n  - error\n"), 0o644)
+		require.NoError(t, err)
+
+		policy, err := LoadRedactionPolicy(file)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"label": "build"}, policy.Redact(map[string]string{
+			"label":           "build",
+			"error":           "some error",
+			"system.hostname": "my-laptop",
+		}))
+	})
+}
+
+func TestSetupWithInvalidRedactionPolicy(t *testing.T) {
+	policyFile := path.Join(t.TempDir(), "policy.yaml")
+	require.NoError(t, os.WriteFile(policyFile, []byte("hostname: obfuscate\n"), 0o644))
+	t.Setenv("SHUTTLE_REMOTE_TRACING", "default")
+	t.Setenv("SHUTTLE_REMOTE_LOG_LOCATION", t.TempDir())
+	t.Setenv(envRedactionPolicy, policyFile)
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() {
+		log.SetOutput(os.Stderr)
+		client = &noopClient
+	})
+
+	Setup()
+
+	jsonLinesClient, ok := client.(*JsonLinesTelemetryClient)
+	require.True(t, ok, "expected a json lines client, got %T", client)
+	assert.Nil(t, jsonLinesClient.redaction, "expected the default redaction policy")
+	assert.Contains(t, logs.String(), "failed to load telemetry redaction policy, using the default policy")
+}
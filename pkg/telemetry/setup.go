@@ -30,6 +30,13 @@ func Setup() {
 		sysinfo := WithGoInfo()
 		sysinfo(properties)
 
+		redaction, err := RedactionPolicyFromEnv()
+		if err != nil {
+			// a broken policy must not fail every command, and the default policy still hashes the hostname
+			log.Printf("failed to load telemetry redaction policy, using the default policy: %v", err)
+			redaction = nil
+		}
+
 		logLocation := getRemoteLogLocation()
 		if logLocation != "" {
 			if err := os.MkdirAll(logLocation, 0o755); err != nil {
@@ -42,6 +49,7 @@ func Setup() {
 			properties:  properties,
 			logLocation: logLocation,
 			Client:      http.DefaultClient,
+			redaction:   redaction,
 		}
 
 		return
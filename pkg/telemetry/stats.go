@@ -0,0 +1,229 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Run is a single traced shuttle invocation, pieced together from its start, error and end events
+type Run struct {
+	RunID      string        `json:"runId"`
+	ContextID  string        `json:"contextId"`
+	Command    string        `json:"command"`
+	Start      time.Time     `json:"start"`
+	End        time.Time     `json:"end"`
+	Duration   time.Duration `json:"-"`
+	DurationMs int64         `json:"durationMs"`
+	Completed  bool          `json:"completed"`
+	Failed     bool          `json:"failed"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// CommandStats aggregates the runs of a single command or script
+type CommandStats struct {
+	Command     string        `json:"command"`
+	Runs        int           `json:"runs"`
+	Failures    int           `json:"failures"`
+	FailureRate float64       `json:"failureRate"`
+	P50         time.Duration `json:"-"`
+	P95         time.Duration `json:"-"`
+	P50Ms       int64         `json:"p50Ms"`
+	P95Ms       int64         `json:"p95Ms"`
+}
+
+// Stats is the local analytics over recorded telemetry
+type Stats struct {
+	Events   int            `json:"events"`
+	Runs     int            `json:"runs"`
+	Commands []CommandStats `json:"commands"`
+	Slowest  []Run          `json:"slowest"`
+}
+
+// RemoteLogLocation returns the folder the JsonLinesTelemetryClient writes telemetry files to
+func RemoteLogLocation() string {
+	return getRemoteLogLocation()
+}
+
+// ReadEvents reads all trace events from the telemetry files in location, including files currently being written to
+func ReadEvents(ctx context.Context, location string) ([]UploadTraceEvent, error) {
+	files, err := getTelemetryFiles(ctx, location)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]UploadTraceEvent, 0)
+	for _, file := range files {
+		fileEvents, _, err := getTelemetryFile(ctx, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read events from shuttle telemetry file: %s, err: %w", file, err)
+		}
+		events = append(events, fileEvents...)
+	}
+
+	return events, nil
+}
+
+// Runs pairs start and end events by their run ID. Events without a run ID are ignored, and only the events of the
+// command itself start, end or fail a run, see isCommandEvent.
+// Runs are sorted by their start time, newest first
+func Runs(events []UploadTraceEvent) []Run {
+	runs := make(map[string]*Run)
+	for _, event := range events {
+		runID := event.Properties[telemetryRunID]
+		if runID == "" {
+			continue
+		}
+
+		run, ok := runs[runID]
+		if !ok {
+			run = &Run{RunID: runID}
+			runs[runID] = run
+		}
+		if run.ContextID == "" {
+			run.ContextID = event.Properties[telemetryContextID]
+		}
+		if run.Command == "" {
+			run.Command = commandOf(event)
+		}
+		if !isCommandEvent(event) {
+			continue
+		}
+
+		switch event.Properties["phase"] {
+		case "start":
+			run.Start = event.Timestamp
+		case "end":
+			run.End = event.Timestamp
+		case "error":
+			run.Failed = true
+			run.Error = event.Properties["error"]
+		}
+	}
+
+	result := make([]Run, 0, len(runs))
+	for _, run := range runs {
+		if !run.Start.IsZero() && !run.End.IsZero() {
+			run.Completed = true
+			run.Duration = run.End.Sub(run.Start)
+			run.DurationMs = run.Duration.Milliseconds()
+		}
+		result = append(result, *run)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].Start.Equal(result[j].Start) {
+			return result[i].Start.After(result[j].Start)
+		}
+		return result[i].RunID < result[j].RunID
+	})
+
+	return result
+}
+
+// Aggregate computes statistics per command over runs, along with the slowest runs
+func Aggregate(events []UploadTraceEvent, slowest int) Stats {
+	runs := Runs(events)
+
+	byCommand := make(map[string][]Run)
+	for _, run := range runs {
+		byCommand[run.Command] = append(byCommand[run.Command], run)
+	}
+
+	commands := make([]CommandStats, 0, len(byCommand))
+	for command, commandRuns := range byCommand {
+		stats := CommandStats{
+			Command: command,
+			Runs:    len(commandRuns),
+		}
+		durations := make([]time.Duration, 0, len(commandRuns))
+		for _, run := range commandRuns {
+			if run.Failed {
+				stats.Failures++
+			}
+			if run.Completed {
+				durations = append(durations, run.Duration)
+			}
+		}
+		stats.FailureRate = float64(stats.Failures) / float64(stats.Runs)
+		stats.P50 = percentile(durations, 50)
+		stats.P95 = percentile(durations, 95)
+		stats.P50Ms = stats.P50.Milliseconds()
+		stats.P95Ms = stats.P95.Milliseconds()
+		commands = append(commands, stats)
+	}
+	sort.Slice(commands, func(i, j int) bool {
+		if commands[i].Runs != commands[j].Runs {
+			return commands[i].Runs > commands[j].Runs
+		}
+		return commands[i].Command < commands[j].Command
+	})
+
+	completed := make([]Run, 0, len(runs))
+	for _, run := range runs {
+		if run.Completed {
+			completed = append(completed, run)
+		}
+	}
+	sort.SliceStable(completed, func(i, j int) bool {
+		return completed[i].Duration > completed[j].Duration
+	})
+	if slowest >= 0 && len(completed) > slowest {
+		completed = completed[:slowest]
+	}
+
+	return Stats{
+		Events:   len(events),
+		Runs:     len(runs),
+		Commands: commands,
+		Slowest:  completed,
+	}
+}
+
+// FilterEventsSince leaves out events recorded before since
+func FilterEventsSince(events []UploadTraceEvent, since time.Time) []UploadTraceEvent {
+	filtered := make([]UploadTraceEvent, 0, len(events))
+	for _, event := range events {
+		if event.Timestamp.Before(since) {
+			continue
+		}
+		filtered = append(filtered, event)
+	}
+
+	return filtered
+}
+
+// isCommandEvent reports whether the event is traced by the command itself. Nested operations, like golang_action,
+// share the run ID of the command and trace their own end and error phases
+func isCommandEvent(event UploadTraceEvent) bool {
+	command := event.Properties[TelemetryCommand]
+	return command == "" || command == event.Properties["label"]
+}
+
+func commandOf(event UploadTraceEvent) string {
+	if command := event.Properties[TelemetryCommand]; command != "" {
+		return command
+	}
+
+	return event.Properties["label"]
+}
+
+// percentile uses the nearest-rank method
+func percentile(durations []time.Duration, p float64) time.Duration {
+	if len(durations) == 0 {
+		return 0
+	}
+
+	sorted := make([]time.Duration, len(durations))
+	copy(sorted, durations)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+
+	return sorted[rank-1]
+}
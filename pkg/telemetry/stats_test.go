@@ -0,0 +1,109 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStats(t *testing.T) {
+	t.Parallel()
+
+	base := time.Date(2023, time.July, 17, 15, 0, 0, 0, time.UTC)
+	event := func(runID, command, phase string, offset time.Duration) UploadTraceEvent {
+		return UploadTraceEvent{
+			App:       appKey,
+			Timestamp: base.Add(offset),
+			Properties: map[string]string{
+				telemetryRunID:   runID,
+				TelemetryCommand: command,
+				"label":          command,
+				"phase":          phase,
+			},
+		}
+	}
+
+	action := func(runID, command, phase string, offset time.Duration) UploadTraceEvent {
+		e := event(runID, command, phase, offset)
+		e.Properties["label"] = "golang_action"
+		return e
+	}
+
+	events := []UploadTraceEvent{
+		event("1", "build", "start", 0),
+		event("1", "build", "end", 10*time.Second),
+		event("2", "build", "start", time.Minute),
+		event("2", "build", "end", time.Minute+20*time.Second),
+		event("3", "build", "start", 2*time.Minute),
+		event("3", "build", "error", 2*time.Minute+time.Second),
+		event("3", "build", "end", 2*time.Minute+30*time.Second),
+		event("4", "test", "start", 3*time.Minute),
+		// golang actions trace their own phases with the run ID of the script
+		action("4", "test", "end", 3*time.Minute+500*time.Millisecond),
+		action("4", "test", "error", 3*time.Minute+600*time.Millisecond),
+		event("4", "test", "end", 3*time.Minute+time.Second),
+		// still running
+		event("5", "test", "start", 4*time.Minute),
+		// missing run id
+		{App: appKey, Timestamp: base, Properties: map[string]string{"label": "init"}},
+	}
+
+	t.Run("runs", func(t *testing.T) {
+		t.Parallel()
+
+		runs := Runs(events)
+		require.Len(t, runs, 5)
+		assert.Equal(t, "5", runs[0].RunID, "newest first")
+		assert.False(t, runs[0].Completed)
+		assert.Equal(t, "3", runs[2].RunID)
+		assert.True(t, runs[2].Failed)
+		assert.Equal(t, 30*time.Second, runs[2].Duration)
+		assert.Equal(t, "4", runs[1].RunID)
+		assert.False(t, runs[1].Failed, "the script succeeded despite a failing golang action")
+		assert.Equal(t, time.Second, runs[1].Duration)
+	})
+
+	t.Run("aggregate", func(t *testing.T) {
+		t.Parallel()
+
+		stats := Aggregate(events, 2)
+		assert.Equal(t, len(events), stats.Events)
+		assert.Equal(t, 5, stats.Runs)
+		require.Len(t, stats.Commands, 2)
+
+		build := stats.Commands[0]
+		assert.Equal(t, "build", build.Command)
+		assert.Equal(t, 3, build.Runs)
+		assert.Equal(t, 1, build.Failures)
+		assert.InDelta(t, 1.0/3.0, build.FailureRate, 0.0001)
+		assert.Equal(t, 20*time.Second, build.P50)
+		assert.Equal(t, 30*time.Second, build.P95)
+
+		testStats := stats.Commands[1]
+		assert.Equal(t, "test", testStats.Command)
+		assert.Equal(t, 2, testStats.Runs)
+		assert.Equal(t, time.Second, testStats.P50)
+
+		require.Len(t, stats.Slowest, 2)
+		assert.Equal(t, "3", stats.Slowest[0].RunID)
+		assert.Equal(t, "2", stats.Slowest[1].RunID)
+	})
+
+	t.Run("filter since", func(t *testing.T) {
+		t.Parallel()
+
+		filtered := FilterEventsSince(events, base.Add(3*time.Minute))
+		assert.Len(t, filtered, 5)
+	})
+
+	t.Run("read events", func(t *testing.T) {
+		t.Parallel()
+
+		events, err := ReadEvents(context.Background(), "testdata/get-shuttle-telemetry-file")
+		require.NoError(t, err)
+		assert.Len(t, events, 2)
+	})
+}
@@ -3,20 +3,30 @@ package telemetry
 import (
 	"bufio"
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"log"
 	"net/http"
 	"os"
 	"path"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+)
 
-	"golang.org/x/sync/errgroup"
+const (
+	defaultBatchSize      = 500
+	defaultMaxAttempts    = 5
+	defaultInitialBackoff = 500 * time.Millisecond
+	defaultMaxBackoff     = 30 * time.Second
+	defaultMinFileAge     = time.Minute
+	lockStaleAfter        = 5 * time.Minute
+	lockFileName          = ".shuttle-telemetry-lock"
 )
 
 type (
@@ -27,6 +37,13 @@ type (
 
 		storageLocation string
 		cleanUp         bool
+		compress        bool
+		batchSize       int
+		minFileAge      time.Duration
+		maxAttempts     int
+		initialBackoff  time.Duration
+		maxBackoff      time.Duration
+		redaction       *RedactionPolicy
 
 		upload            UploadFunc
 		getTelemetryFiles GetTelemetryFilesFunc
@@ -56,6 +73,43 @@ type (
 	UploadOptions = func(*TelemetryUploader)
 )
 
+// UploadStatusError is returned by the default upload function when the
+// server responds with a non 2xx status code
+type UploadStatusError struct {
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration
+}
+
+func (e *UploadStatusError) Error() string {
+	return fmt.Sprintf(
+		"failed to push trace event with status code: %d, reason: %s",
+		e.StatusCode,
+		e.Body,
+	)
+}
+
+// Retryable reports whether the request may succeed if sent again
+func (e *UploadStatusError) Retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// FileUploadError is reported for each telemetry file which couldn't be read
+// or whose events couldn't be uploaded. The file is left in place for the next
+// upload
+type FileUploadError struct {
+	File string
+	Err  error
+}
+
+func (e *FileUploadError) Error() string {
+	return fmt.Sprintf("telemetry file %s: %s", e.File, e.Err)
+}
+
+func (e *FileUploadError) Unwrap() error {
+	return e.Err
+}
+
 // WithRate sets the current rate of which events will be uploaded
 func WithRate(rate time.Duration) UploadOptions {
 	return func(tu *TelemetryUploader) {
@@ -92,13 +146,58 @@ func WithRemoteLogLocation(location string) UploadOptions {
 }
 
 // WithCleanUp determines whether to remove telemetry files after they've been read.
-// There are no consistency guarantees that no duplicates will be uploaded on error if run, or dropped traces
+// Files are only removed once all of their events have been uploaded
 func WithCleanUp(enabled bool) UploadOptions {
 	return func(tu *TelemetryUploader) {
 		tu.cleanUp = enabled
 	}
 }
 
+// WithCompression determines whether request bodies are gzip compressed by the default upload function
+func WithCompression(enabled bool) UploadOptions {
+	return func(tu *TelemetryUploader) {
+		tu.compress = enabled
+	}
+}
+
+// WithBatchSize sets the maximum amount of events sent in a single request.
+// Events from several files may be combined into one batch, and a file may be split across batches.
+// If a later batch of a file fails, the file is reduced to the events which weren't uploaded
+func WithBatchSize(size int) UploadOptions {
+	return func(tu *TelemetryUploader) {
+		if size > 0 {
+			tu.batchSize = size
+		}
+	}
+}
+
+// WithMinFileAge skips telemetry files which have been modified more recently than age,
+// as they may still be written to by a running shuttle process
+func WithMinFileAge(age time.Duration) UploadOptions {
+	return func(tu *TelemetryUploader) {
+		tu.minFileAge = age
+	}
+}
+
+// WithRetry sets how many times a batch is attempted when the server responds with 429 or 5xx,
+// and the initial backoff, which is doubled for each attempt
+func WithRetry(maxAttempts int, initialBackoff time.Duration) UploadOptions {
+	return func(tu *TelemetryUploader) {
+		if maxAttempts < 1 {
+			maxAttempts = 1
+		}
+		tu.maxAttempts = maxAttempts
+		tu.initialBackoff = initialBackoff
+	}
+}
+
+// WithRedactionPolicy redacts events before they are uploaded. This also covers files written before the policy was set
+func WithRedactionPolicy(policy *RedactionPolicy) UploadOptions {
+	return func(tu *TelemetryUploader) {
+		tu.redaction = policy
+	}
+}
+
 // WithAvailabilityCheck adds a check for the upload location, this is useful if there are scenarios where the upload
 // process shouldn't be run. I.e. you're not on a vpn, or on a slow internet connection
 func WithAvailabilityCheck(url string) UploadOptions {
@@ -139,7 +238,6 @@ func NewTelemetryUploader(url string, options ...UploadOptions) *TelemetryUpload
 	storageLocation := getRemoteLogLocation()
 	uploader := &TelemetryUploader{
 		url:               url,
-		upload:            upload,
 		getTelemetryFiles: getTelemetryFiles,
 		getTelemetryFile:  getTelemetryFile,
 		availabilityCheck: func(ctx context.Context) (bool, error) {
@@ -147,6 +245,12 @@ func NewTelemetryUploader(url string, options ...UploadOptions) *TelemetryUpload
 		},
 		storageLocation: storageLocation,
 		cleanUp:         true,
+		compress:        true,
+		batchSize:       defaultBatchSize,
+		minFileAge:      defaultMinFileAge,
+		maxAttempts:     defaultMaxAttempts,
+		initialBackoff:  defaultInitialBackoff,
+		maxBackoff:      defaultMaxBackoff,
 		lock:            lockFunc(storageLocation),
 	}
 
@@ -154,10 +258,36 @@ func NewTelemetryUploader(url string, options ...UploadOptions) *TelemetryUpload
 		o(uploader)
 	}
 
+	if uploader.upload == nil {
+		uploader.upload = uploadFunc(http.DefaultClient, uploader.compress)
+	}
+
 	return uploader
 }
 
+// telemetryFile tracks the events read from a single file, and whether all of them made it to the server
+type telemetryFile struct {
+	path    string
+	events  []UploadTraceEvent
+	cleanUp func(ctx context.Context) error
+	err     error
+	// uploaded is the amount of events from the start of the file which have been uploaded
+	uploaded int
+}
+
+type uploadBatch struct {
+	parts []batchPart
+}
+
+// batchPart is the events of file from start to end included in a batch
+type batchPart struct {
+	file       *telemetryFile
+	start, end int
+}
+
 // Upload kicks off the file upload process. This involves reading trace event files and uploading them to a determined location.
+// Events are sent in batches, and a failure for one file doesn't prevent events from other files from being uploaded.
+// Failures are returned as a joined set of FileUploadError.
 // See Options (With*) for extra documentation
 func (tu *TelemetryUploader) Upload(ctx context.Context) error {
 	// Makes sure only a single upload process is run for each instance
@@ -172,12 +302,12 @@ func (tu *TelemetryUploader) Upload(ctx context.Context) error {
 		log.Println("file is already locked returning")
 		return nil
 	}
-	defer func(ctx context.Context, unlock UnlockFunc) {
-		return
-		if err := unlock(ctx); err != nil {
+	defer func() {
+		// the upload context may have been cancelled, but the lock should still be released
+		if err := unlock(context.WithoutCancel(ctx)); err != nil {
 			log.Printf("failed to clean up lock: %s", err)
 		}
-	}(ctx, unlock)
+	}()
 
 	ok, err := tu.availabilityCheck(ctx)
 	if err != nil {
@@ -194,65 +324,249 @@ func (tu *TelemetryUploader) Upload(ctx context.Context) error {
 		return fmt.Errorf("failed to get telemetry files: %w", err)
 	}
 
-	egrp, ctx := errgroup.WithContext(ctx)
-	for _, file := range files {
-		file := file
-		egrp.Go(func() error {
-			events, cleanUpFunc, err := tu.getTelemetryFile(ctx, file)
-			if err != nil {
-				return fmt.Errorf(
-					"failed to read events from shuttle telemetry file: %s, err: %w",
-					file,
-					err,
-				)
+	telemetryFiles := tu.readTelemetryFiles(ctx, tu.filterByAge(files))
+
+	for _, batch := range tu.batch(telemetryFiles) {
+		// the remaining events of files failing in an earlier batch are left out, so they aren't uploaded twice
+		parts := make([]batchPart, 0, len(batch.parts))
+		events := make([]UploadTraceEvent, 0, tu.batchSize)
+		for _, part := range batch.parts {
+			if part.file.err != nil {
+				continue
 			}
+			parts = append(parts, part)
+			events = append(events, part.file.events[part.start:part.end]...)
+		}
+		if len(events) == 0 {
+			continue
+		}
 
-			if err := tu.upload(ctx, tu.url, events); err != nil {
-				return fmt.Errorf("failed to upload events: %w", err)
+		if err := tu.uploadWithRetry(ctx, events); err != nil {
+			for _, part := range parts {
+				part.file.err = fmt.Errorf("failed to upload events: %w", err)
 			}
+			continue
+		}
+		for _, part := range parts {
+			part.file.uploaded = part.end
+		}
+	}
 
-			if tu.cleanUp {
-				if err := cleanUpFunc(ctx); err != nil {
-					return err
+	errs := make([]error, 0)
+	for _, file := range telemetryFiles {
+		if file.err != nil {
+			errs = append(errs, &FileUploadError{File: file.path, Err: file.err})
+			if tu.cleanUp && file.cleanUp != nil && file.uploaded > 0 {
+				if err := keepRemainingEvents(file); err != nil {
+					errs = append(errs, &FileUploadError{File: file.path, Err: err})
 				}
 			}
+			continue
+		}
 
-			return nil
+		if tu.cleanUp && file.cleanUp != nil {
+			if err := file.cleanUp(ctx); err != nil {
+				errs = append(errs, &FileUploadError{File: file.path, Err: err})
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// filterByAge leaves out files which may still be written to
+func (tu *TelemetryUploader) filterByAge(files []string) []string {
+	if tu.minFileAge <= 0 {
+		return files
+	}
+
+	threshold := time.Now().Add(-tu.minFileAge)
+	filtered := make([]string, 0, len(files))
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		if info.ModTime().After(threshold) {
+			continue
+		}
+		filtered = append(filtered, file)
+	}
+
+	return filtered
+}
+
+func (tu *TelemetryUploader) readTelemetryFiles(
+	ctx context.Context,
+	files []string,
+) []*telemetryFile {
+	telemetryFiles := make([]*telemetryFile, 0, len(files))
+	for _, file := range files {
+		events, cleanUp, err := tu.getTelemetryFile(ctx, file)
+		if err != nil {
+			err = fmt.Errorf("failed to read events from shuttle telemetry file: %w", err)
+		}
+		telemetryFiles = append(telemetryFiles, &telemetryFile{
+			path:    file,
+			events:  tu.redaction.RedactEvents(events),
+			cleanUp: cleanUp,
+			err:     err,
 		})
 	}
 
-	if err := egrp.Wait(); err != nil {
-		return err
+	return telemetryFiles
+}
+
+// batch packs the events of all readable files into batches of at most batchSize events.
+// A file may span several batches, in which case Upload tracks how many of its events are uploaded
+func (tu *TelemetryUploader) batch(files []*telemetryFile) []uploadBatch {
+	batches := make([]uploadBatch, 0)
+	current := uploadBatch{}
+	size := 0
+	flush := func() {
+		if size > 0 {
+			batches = append(batches, current)
+		}
+		current = uploadBatch{}
+		size = 0
 	}
 
-	return nil
+	for _, file := range files {
+		if file.err != nil {
+			continue
+		}
+		for start := 0; start < len(file.events); {
+			end := min(start+tu.batchSize-size, len(file.events))
+			current.parts = append(current.parts, batchPart{file: file, start: start, end: end})
+			size += end - start
+			start = end
+
+			if size >= tu.batchSize {
+				flush()
+			}
+		}
+	}
+	flush()
+
+	return batches
 }
 
-func upload(ctx context.Context, url string, events []UploadTraceEvent) error {
-	content, err := json.Marshal(events)
-	if err != nil {
-		return err
+// keepRemainingEvents replaces the content of a partially uploaded file with the events which weren't uploaded, so
+// they are uploaded on the next run without duplicating the others. The events are written redacted
+func keepRemainingEvents(file *telemetryFile) error {
+	var content bytes.Buffer
+	encoder := json.NewEncoder(&content)
+	for _, event := range file.events[file.uploaded:] {
+		if err := encoder.Encode(event); err != nil {
+			return err
+		}
 	}
 
-	client := http.DefaultClient
+	tmpFile := file.path + ".tmp"
+	if err := os.WriteFile(tmpFile, content.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to keep events which weren't uploaded: %w", err)
+	}
+	if err := os.Rename(tmpFile, file.path); err != nil {
+		_ = os.Remove(tmpFile)
+		return fmt.Errorf("failed to keep events which weren't uploaded: %w", err)
+	}
 
-	resp, err := client.Post(url, "application/json", bytes.NewReader(content))
-	if err != nil {
-		return err
+	return nil
+}
+
+// uploadWithRetry retries the upload with exponential backoff as long as the error is retryable
+func (tu *TelemetryUploader) uploadWithRetry(ctx context.Context, events []UploadTraceEvent) error {
+	backoff := tu.initialBackoff
+	for attempt := 1; ; attempt++ {
+		err := tu.upload(ctx, tu.url, events)
+		if err == nil {
+			return nil
+		}
+
+		var statusErr *UploadStatusError
+		if !errors.As(err, &statusErr) || !statusErr.Retryable() || attempt >= tu.maxAttempts {
+			return err
+		}
+
+		wait := backoff
+		if statusErr.RetryAfter > wait {
+			wait = statusErr.RetryAfter
+		}
+		if tu.maxBackoff > 0 && wait > tu.maxBackoff {
+			wait = tu.maxBackoff
+		}
+		log.Printf("upload failed with status code: %d, retrying in %s", statusErr.StatusCode, wait)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+
+		backoff *= 2
 	}
-	if resp.StatusCode > 299 {
-		body, err := ioutil.ReadAll(resp.Body)
+}
+
+func uploadFunc(client *http.Client, compress bool) UploadFunc {
+	return func(ctx context.Context, url string, events []UploadTraceEvent) error {
+		content, err := json.Marshal(events)
+		if err != nil {
+			return err
+		}
+
+		if compress {
+			var buf bytes.Buffer
+			writer := gzip.NewWriter(&buf)
+			if _, err := writer.Write(content); err != nil {
+				return err
+			}
+			if err := writer.Close(); err != nil {
+				return err
+			}
+			content = buf.Bytes()
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(content))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if compress {
+			req.Header.Set("Content-Encoding", "gzip")
+		}
+
+		resp, err := client.Do(req)
 		if err != nil {
 			return err
 		}
-		return fmt.Errorf(
-			"failed to push trace event with status code: %d, reason: %s",
-			resp.StatusCode,
-			string(body),
-		)
+		defer resp.Body.Close()
+
+		if resp.StatusCode > 299 {
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				return err
+			}
+			return &UploadStatusError{
+				StatusCode: resp.StatusCode,
+				Body:       string(body),
+				RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+			}
+		}
+
+		return nil
 	}
+}
 
-	return nil
+// parseRetryAfter supports the delay-seconds form of the Retry-After header
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+
+	return time.Duration(seconds) * time.Second
 }
 
 func getTelemetryFiles(ctx context.Context, location string) ([]string, error) {
@@ -279,8 +593,6 @@ func getTelemetryFiles(ctx context.Context, location string) ([]string, error) {
 		if strings.HasPrefix(fileName, fileNameShuttleJsonLines) &&
 			strings.HasSuffix(fileName, extensionShuttleJsonLines) &&
 			isFile {
-			// TODO: only read files older than a certain threshold
-
 			shuttleTelemetryFiles = append(shuttleTelemetryFiles, path.Join(location, fileName))
 		}
 	}
@@ -299,11 +611,15 @@ func getTelemetryFile(
 		}
 		return nil, nil, err
 	}
+	defer file.Close()
 
 	events := make([]UploadTraceEvent, 0)
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
 		line := scanner.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
 
 		var event UploadTraceEvent
 		err := json.Unmarshal(line, &event)
@@ -336,6 +652,7 @@ func availabilityCheck(ctx context.Context, url string) (bool, error) {
 			log.Printf("checking endpoint failed with: %s", err)
 			return false, nil
 		}
+		defer resp.Body.Close()
 
 		if resp.StatusCode != 200 {
 			log.Printf("status code is not 200: status=%d", resp.StatusCode)
@@ -364,26 +681,29 @@ func lockFunc(storageLocation string) LockFunc {
 		return nil
 	}
 
-	checkLockExists := func(lockFile string) (exists bool, err error) {
+	// removeStaleLock removes a lock left behind by a process which didn't clean up after itself
+	removeStaleLock := func(lockFile string) error {
 		file, err := os.Stat(lockFile)
 		if err != nil {
 			if errors.Is(err, os.ErrNotExist) {
-				return false, nil
+				return nil
 			}
 
-			return false, err
+			return err
 		}
 
-		if file.ModTime().Add(time.Minute * 5).Before(time.Now()) {
-			return false, nil
+		if file.ModTime().Add(lockStaleAfter).Before(time.Now()) {
+			if err := os.Remove(lockFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return err
+			}
 		}
 
-		return true, nil
+		return nil
 	}
 
 	unlockFunc := func(lockFile string) func(ctx context.Context) error {
 		return func(ctx context.Context) error {
-			if err := os.Remove(lockFile); err != nil {
+			if err := os.Remove(lockFile); err != nil && !errors.Is(err, os.ErrNotExist) {
 				return err
 			}
 
@@ -395,16 +715,20 @@ func lockFunc(storageLocation string) LockFunc {
 		if err := handleFolderExists(); err != nil {
 			return nil, false, err
 		}
-		lockFile := path.Join(storageLocation, ".shuttle-telemetry-lock")
-		exists, err := checkLockExists(lockFile)
-		if err != nil {
+		lockFile := path.Join(storageLocation, lockFileName)
+		if err := removeStaleLock(lockFile); err != nil {
 			return nil, false, err
 		}
-		if exists {
-			return nil, true, nil
-		}
 
-		if _, err := os.Create(lockFile); err != nil {
+		// O_EXCL makes creating the lock atomic, so only a single process can acquire it
+		file, err := os.OpenFile(lockFile, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err != nil {
+			if errors.Is(err, os.ErrExist) {
+				return nil, true, nil
+			}
+			return nil, false, err
+		}
+		if err := file.Close(); err != nil {
 			return nil, false, err
 		}
 
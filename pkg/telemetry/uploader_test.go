@@ -1,13 +1,17 @@
 package telemetry
 
 import (
+	"compress/gzip"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net"
 	"net/http"
 	"os"
 	"path"
+	"sync"
 	"testing"
 	"time"
 
@@ -229,6 +233,231 @@ func TestTelemetryUploader(t *testing.T) {
 	})
 }
 
+func TestTelemetryUploaderUpload(t *testing.T) {
+	t.Parallel()
+
+	writeTelemetryFile := func(t *testing.T, dir, name string, events int, age time.Duration) string {
+		t.Helper()
+		filePath := path.Join(dir, name)
+		content := ""
+		for i := 0; i < events; i++ {
+			content += fmt.Sprintf(`{"app": "shuttle", "timestamp": "2023-07-17T15:21:27Z", "properties": {"i": "%d"}}`, i) + "\n"
+		}
+		require.NoError(t, os.WriteFile(filePath, []byte(content), 0o644))
+		modTime := time.Now().Add(-age)
+		require.NoError(t, os.Chtimes(filePath, modTime, modTime))
+
+		return filePath
+	}
+
+	t.Run("batches events across files and cleans up", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		writeTelemetryFile(t, dir, "shuttle-telemetry-a.jsonl", 3, time.Hour)
+		writeTelemetryFile(t, dir, "shuttle-telemetry-b.jsonl", 2, time.Hour)
+
+		var batches []int
+		uploader := NewTelemetryUploader(
+			"some-url",
+			WithRemoteLogLocation(dir),
+			WithFileLock(dir),
+			WithBatchSize(2),
+			WithUploadFunction(
+				func(ctx context.Context, url string, events []UploadTraceEvent) error {
+					batches = append(batches, len(events))
+					return nil
+				},
+			),
+		)
+
+		err := uploader.Upload(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []int{2, 2, 1}, batches)
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Empty(t, entries, "telemetry files and lock should be removed")
+	})
+
+	t.Run("keeps only the events which weren't uploaded when a later batch of a file fails", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		file := writeTelemetryFile(t, dir, "shuttle-telemetry-a.jsonl", 5, time.Hour)
+
+		var uploaded []string
+		fail := true
+		uploader := NewTelemetryUploader(
+			"some-url",
+			WithRemoteLogLocation(dir),
+			WithNoLock(),
+			WithMinFileAge(0),
+			WithBatchSize(2),
+			WithRetry(1, 0),
+			WithUploadFunction(
+				func(ctx context.Context, url string, events []UploadTraceEvent) error {
+					if fail && len(uploaded) == 2 {
+						return errors.New("upload failed")
+					}
+					for _, event := range events {
+						uploaded = append(uploaded, event.Properties["i"])
+					}
+					return nil
+				},
+			),
+		)
+
+		err := uploader.Upload(context.Background())
+		require.Error(t, err)
+		assert.Equal(t, []string{"0", "1"}, uploaded)
+		assert.FileExists(t, file)
+
+		fail = false
+		err = uploader.Upload(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"0", "1", "2", "3", "4"}, uploaded, "events should be uploaded once")
+		assert.NoFileExists(t, file)
+	})
+
+	t.Run("skips files which are too new", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		writeTelemetryFile(t, dir, "shuttle-telemetry-old.jsonl", 1, time.Hour)
+		newFile := writeTelemetryFile(t, dir, "shuttle-telemetry-new.jsonl", 1, 0)
+
+		uploaded := 0
+		uploader := NewTelemetryUploader(
+			"some-url",
+			WithRemoteLogLocation(dir),
+			WithNoLock(),
+			WithMinFileAge(time.Minute),
+			WithUploadFunction(
+				func(ctx context.Context, url string, events []UploadTraceEvent) error {
+					uploaded += len(events)
+					return nil
+				},
+			),
+		)
+
+		err := uploader.Upload(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, uploaded)
+		assert.FileExists(t, newFile)
+	})
+
+	t.Run("failing file doesn't lose other files", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		okFile := writeTelemetryFile(t, dir, "shuttle-telemetry-ok.jsonl", 1, time.Hour)
+		badFile := path.Join(dir, "shuttle-telemetry-bad.jsonl")
+		require.NoError(t, os.WriteFile(badFile, []byte("not json\n"), 0o644))
+		old := time.Now().Add(-time.Hour)
+		require.NoError(t, os.Chtimes(badFile, old, old))
+
+		uploaded := 0
+		uploader := NewTelemetryUploader(
+			"some-url",
+			WithRemoteLogLocation(dir),
+			WithNoLock(),
+			WithUploadFunction(
+				func(ctx context.Context, url string, events []UploadTraceEvent) error {
+					uploaded += len(events)
+					return nil
+				},
+			),
+		)
+
+		err := uploader.Upload(context.Background())
+		var fileErr *FileUploadError
+		require.ErrorAs(t, err, &fileErr)
+		assert.Equal(t, badFile, fileErr.File)
+		assert.Equal(t, 1, uploaded)
+		assert.NoFileExists(t, okFile)
+		assert.FileExists(t, badFile)
+	})
+
+	t.Run("retries on retryable status codes with gzip body", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		writeTelemetryFile(t, dir, "shuttle-telemetry-a.jsonl", 2, time.Hour)
+
+		var (
+			mu       sync.Mutex
+			attempts int
+			received []UploadTraceEvent
+		)
+		server := startServer(
+			t,
+			func() (string, func(w http.ResponseWriter, r *http.Request)) {
+				return "/upload", func(w http.ResponseWriter, r *http.Request) {
+					mu.Lock()
+					defer mu.Unlock()
+					attempts++
+					if attempts == 1 {
+						w.WriteHeader(http.StatusServiceUnavailable)
+						return
+					}
+					if attempts == 2 {
+						w.WriteHeader(http.StatusTooManyRequests)
+						return
+					}
+
+					assert.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+					// FailNow can't be called from the handler goroutine, so failures are only recorded
+					reader, err := gzip.NewReader(r.Body)
+					if !assert.NoError(t, err) {
+						w.WriteHeader(http.StatusBadRequest)
+						return
+					}
+					if !assert.NoError(t, json.NewDecoder(reader).Decode(&received)) {
+						w.WriteHeader(http.StatusBadRequest)
+						return
+					}
+					w.WriteHeader(http.StatusOK)
+				}
+			},
+		)
+		defer server.Close()
+
+		uploader := NewTelemetryUploader(
+			fmt.Sprintf("http://%s/upload", server.Addr),
+			WithRemoteLogLocation(dir),
+			WithNoLock(),
+			WithRetry(3, time.Millisecond),
+		)
+
+		err := uploader.Upload(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 3, attempts)
+		assert.Len(t, received, 2)
+	})
+
+	t.Run("doesn't retry client errors", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		file := writeTelemetryFile(t, dir, "shuttle-telemetry-a.jsonl", 1, time.Hour)
+
+		attempts := 0
+		uploader := NewTelemetryUploader(
+			"some-url",
+			WithRemoteLogLocation(dir),
+			WithNoLock(),
+			WithRetry(3, time.Millisecond),
+			WithUploadFunction(
+				func(ctx context.Context, url string, events []UploadTraceEvent) error {
+					attempts++
+					return &UploadStatusError{StatusCode: http.StatusBadRequest}
+				},
+			),
+		)
+
+		err := uploader.Upload(context.Background())
+		var statusErr *UploadStatusError
+		require.ErrorAs(t, err, &statusErr)
+		assert.Equal(t, 1, attempts)
+		assert.FileExists(t, file)
+	})
+}
+
 type serverFunc = func() (string, func(w http.ResponseWriter, r *http.Request))
 
 func startServer(t *testing.T, serverFuncs ...serverFunc) *http.Server {
@@ -258,7 +487,7 @@ func startServer(t *testing.T, serverFuncs ...serverFunc) *http.Server {
 		t.Helper()
 		err := server.Serve(listener)
 		if err != nil && err != http.ErrServerClosed {
-			t.Fatalf("Server error: %v", err)
+			t.Errorf("Server error: %v", err)
 		}
 	}(t)
 
@@ -1,6 +1,7 @@
 package templates
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -38,6 +39,7 @@ func GetFuncMap() template.FuncMap {
 		"trim":           strings.TrimSpace,
 		"upperFirst":     TmplUpperFirst,
 		"rightPad":       TmplRightPad,
+		"required":       TmplRequired,
 	}
 
 	for k, v := range extra {
@@ -47,19 +49,35 @@ func GetFuncMap() template.FuncMap {
 	return f
 }
 
-func TmplGet(path string, input interface{}) interface{} {
-	if !strings.Contains(path, ".") {
-		return getInner(path, input)
-	}
+// GetStrictFuncMap returns the template functions of GetFuncMap, where get, string and int fail on missing vars
+// instead of rendering empty values
+func GetStrictFuncMap() template.FuncMap {
+	return GetStrictFuncMapOf(nil)
+}
 
-	properties := strings.SplitN(path, ".", 2)
-	property := properties[0]
-	step := getInner(property, input)
+// GetStrictFuncMapOf is GetStrictFuncMap reporting the full path of missing vars in vars, i.e. var docker.image for
+// {{ string "image" .Vars.docker }}, instead of the path relative to the value passed to the function
+func GetStrictFuncMapOf(vars interface{}) template.FuncMap {
+	paths := indexVarPaths(vars)
+	f := GetFuncMap()
+	f["get"] = paths.get
+	f["string"] = paths.string
+	f["int"] = paths.int
+	return f
+}
 
-	if step != nil {
-		return TmplGet(properties[1], step)
+// TmplGet returns the value at path in input, see ParsePath, or nil if it is missing or the path is invalid
+func TmplGet(path string, input interface{}) interface{} {
+	value, _, err := Lookup(path, input)
+	if err != nil {
+		return nil
 	}
-	return nil
+	return value
+}
+
+// TmplGetStrict is TmplGet failing if the path is invalid, or the var is missing or null
+func TmplGetStrict(path string, input interface{}) (interface{}, error) {
+	return varPaths(nil).get(path, input)
 }
 
 // template function to convert from log.debug to LOG_DEBUG
@@ -85,6 +103,29 @@ func TmplInt(path string, input interface{}) int {
 	return value.(int)
 }
 
+// TmplStringStrict is TmplString failing if the var is missing
+func TmplStringStrict(path string, input interface{}) (string, error) {
+	return varPaths(nil).string(path, input)
+}
+
+// TmplIntStrict is TmplInt failing if the var is missing or not an int
+func TmplIntStrict(path string, input interface{}) (int, error) {
+	return varPaths(nil).int(path, input)
+}
+
+// TmplRequired returns value, or fails with msg if value is nil or an empty string, i.e.
+//
+//	{{ required "vars.service is required" (get "service" .Vars) }}
+func TmplRequired(msg string, value interface{}) (interface{}, error) {
+	if value == nil {
+		return nil, errors.New(msg)
+	}
+	if s, ok := value.(string); ok && s == "" {
+		return nil, errors.New(msg)
+	}
+	return value, nil
+}
+
 // TmplArray parses a YAML path in the input parameter as an array.
 // If variable found is an array, array values are returned
 // If variable found is a map, the maps values are returned
@@ -118,22 +159,7 @@ func TmplObjectArray(path string, input interface{}) []KeyValuePair {
 	if input == nil {
 		return nil
 	}
-	value := TmplGet(path, input)
-	values := []KeyValuePair{}
-	switch typedValue := value.(type) {
-	case map[interface{}]interface{}:
-		for k, v := range typedValue {
-			values = append(values, KeyValuePair{Key: k.(string), Value: v})
-		}
-	case map[string]interface{}:
-		for k, v := range typedValue {
-			values = append(values, KeyValuePair{Key: k, Value: v})
-		}
-	}
-	sort.Slice(values, func(i, j int) bool {
-		return values[i].Key < values[j].Key
-	})
-	return values
+	return ObjectPairs(TmplGet(path, input))
 }
 
 func TmplIs(a interface{}, b interface{}) bool {
@@ -213,29 +239,19 @@ func TmplGetFiles(directoryPath string) []os.FileInfo {
 	return files
 }
 
-func getInner(property string, input interface{}) interface{} {
-	switch t := input.(type) {
-	default:
-		fmt.Printf("unexpected type %T\n", t) // %T prints whatever type t has
-		panic(fmt.Sprintf("unexpected type %T\n", t))
-	case nil:
-		return nil
+// lookup returns the value of property in input, and whether input is a map containing it
+func lookup(property string, input interface{}) (interface{}, bool) {
+	switch values := input.(type) {
 	case map[interface{}]interface{}:
-		values := input.(map[interface{}]interface{})
-		return values[property]
+		value, ok := values[property]
+		return value, ok
 	case map[string]interface{}:
-		values := input.(map[string]interface{})
-		return values[property]
+		value, ok := values[property]
+		return value, ok
 	case map[string]string:
-		values := input.(map[string]string)
-		return values[property]
-	case []interface{}:
-		return nil
-	case string:
-		return nil
-	case bool:
-		return nil
-	case int:
-		return nil
+		value, ok := values[property]
+		return value, ok
+	default:
+		return nil, false
 	}
 }
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v2"
 )
 
@@ -381,6 +382,39 @@ a:
 				"c",
 			},
 		},
+		{
+			name: "an array index",
+			input: input{
+				path: "a[-1].name",
+				data: fromYaml(`
+a:
+- name: first
+- name: last`),
+			},
+			output: "last",
+		},
+		{
+			name: "a wildcard",
+			input: input{
+				path: "a.*.name",
+				data: fromYaml(`
+a:
+- name: first
+- name: last`),
+			},
+			output: []interface{}{
+				"first",
+				"last",
+			},
+		},
+		{
+			name: "an invalid path",
+			input: input{
+				path: "a..b",
+				data: fromYaml(`a: b`),
+			},
+			output: nil,
+		},
 	}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
@@ -390,6 +424,103 @@ a:
 	}
 }
 
+func TestTmplGetStrict(t *testing.T) {
+	data := fromYaml(`
+a:
+  b: 2
+  n: null
+s: text
+`)
+	tt := []struct {
+		name   string
+		path   string
+		output interface{}
+		err    string
+	}{
+		{name: "nested value", path: "a.b", output: 2},
+		{name: "missing value", path: "a.c", err: "var a.c is missing"},
+		{name: "missing parent", path: "x.b", err: "var x.b is missing"},
+		{name: "null value", path: "a.n", err: "var a.n is missing"},
+		{name: "value of a string", path: "s.b", err: "var s.b is missing"},
+		{name: "invalid path", path: "a[b]", err: "path a[b]: [b] must be an index, * or a quoted key"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			output, err := TmplGetStrict(tc.path, data)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.output, output)
+		})
+	}
+}
+
+func TestTmplIntStrict(t *testing.T) {
+	output, err := TmplIntStrict("b.c", input)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, output)
+
+	_, err = TmplIntStrict("b.h", input)
+	assert.EqualError(t, err, "var b.h is string, not an int")
+}
+
+func TestGetStrictFuncMapOf(t *testing.T) {
+	vars := fromYaml(`
+docker:
+  registry: quay.io
+services:
+  - name: api
+annotations:
+  lunar.tech/owner:
+    team: squad
+`)
+	funcs := GetStrictFuncMapOf(vars)
+	get := funcs["get"].(func(string, interface{}) (interface{}, error))
+	lookup := func(path string) interface{} {
+		value, err := TmplGetStrict(path, vars)
+		require.NoError(t, err)
+		return value
+	}
+
+	tt := []struct {
+		name  string
+		path  string
+		input interface{}
+		err   string
+	}{
+		{name: "vars", path: "docker.image", input: vars, err: "var docker.image is missing"},
+		{name: "map in vars", path: "image", input: lookup("docker"), err: "var docker.image is missing"},
+		{name: "list in vars", path: "[1].name", input: lookup("services"), err: "var services[1].name is missing"},
+		{name: "element of list", path: "port", input: lookup("services[0]"), err: "var services[0].port is missing"},
+		{name: "quoted key", path: "name", input: lookup(`annotations."lunar.tech/owner"`), err: `var annotations."lunar.tech/owner".name is missing`},
+		{name: "value outside vars", path: "image", input: map[string]interface{}{}, err: "var image is missing"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := get(tc.path, tc.input)
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+
+	intFunc := funcs["int"].(func(string, interface{}) (int, error))
+	_, err := intFunc("registry", lookup("docker"))
+	assert.EqualError(t, err, "var docker.registry is string, not an int")
+}
+
+func TestTmplRequired(t *testing.T) {
+	value, err := TmplRequired("a is required", "Easy!")
+	assert.NoError(t, err)
+	assert.Equal(t, "Easy!", value)
+
+	_, err = TmplRequired("a is required", nil)
+	assert.EqualError(t, err, "a is required")
+
+	_, err = TmplRequired("a is required", "")
+	assert.EqualError(t, err, "a is required")
+}
+
 func fromYaml(data string) interface{} {
 	m := make(map[string]interface{})
 	err := yaml.Unmarshal([]byte(data), m)
@@ -0,0 +1,254 @@
+package templates
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PathElement is a single step of a variable path, see ParsePath
+type PathElement struct {
+	// Key is the map key of the element. Unquoted numbers are keys as well, so maps with numeric keys still work
+	Key string
+	// Index is the list index of the element if IsIndex is set. Negative indexes count from the end of the list
+	Index   int
+	IsIndex bool
+	// Wildcard matches every element of a list, or every value of a map ordered by key
+	Wildcard bool
+}
+
+func (e PathElement) String() string {
+	switch {
+	case e.Wildcard:
+		return "*"
+	case e.IsIndex && e.Key == "":
+		return fmt.Sprintf("[%d]", e.Index)
+	case strings.ContainsAny(e.Key, `.[]"`):
+		return strconv.Quote(e.Key)
+	default:
+		return e.Key
+	}
+}
+
+// ParsePath parses a variable path. Elements are separated by dots, and are either
+//
+//   - map keys, i.e. docker.image
+//   - quoted map keys containing dots, i.e. annotations."lunar.tech/owner" or annotations["lunar.tech/owner"]
+//   - list indexes, i.e. services.0.name or services[0].name. Negative indexes count from the end, i.e. ports[-1]
+//   - wildcards matching every element of a list or map, i.e. services.*.name
+func ParsePath(path string) ([]PathElement, error) {
+	if path == "" {
+		return nil, fmt.Errorf("path is empty")
+	}
+
+	elements := make([]PathElement, 0)
+	i := 0
+	for i < len(path) {
+		switch {
+		case strings.HasPrefix(path[i:], `["`):
+			key, n, err := parseQuoted(path[i+1:])
+			if err != nil {
+				return nil, fmt.Errorf("path %s: %w", path, err)
+			}
+			i += n + 1
+			if i == len(path) || path[i] != ']' {
+				return nil, fmt.Errorf("path %s: missing ] after position %d", path, i)
+			}
+			elements = append(elements, PathElement{Key: key})
+			i++
+		case path[i] == '[':
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("path %s: missing ] after position %d", path, i)
+			}
+			element, err := parseBracket(path[i+1 : i+end])
+			if err != nil {
+				return nil, fmt.Errorf("path %s: %w", path, err)
+			}
+			elements = append(elements, element)
+			i += end + 1
+		case path[i] == '"':
+			key, n, err := parseQuoted(path[i:])
+			if err != nil {
+				return nil, fmt.Errorf("path %s: %w", path, err)
+			}
+			elements = append(elements, PathElement{Key: key})
+			i += n
+		default:
+			end := strings.IndexAny(path[i:], ".[")
+			if end < 0 {
+				end = len(path) - i
+			}
+			raw := path[i : i+end]
+			if raw == "" {
+				return nil, fmt.Errorf("path %s: empty element at position %d", path, i)
+			}
+			elements = append(elements, parseElement(raw))
+			i += end
+		}
+
+		if i == len(path) {
+			break
+		}
+		switch path[i] {
+		case '.':
+			i++
+			if i == len(path) {
+				return nil, fmt.Errorf("path %s: ends with .", path)
+			}
+		case '[':
+		default:
+			return nil, fmt.Errorf("path %s: expected . or [ at position %d", path, i)
+		}
+	}
+
+	return elements, nil
+}
+
+func parseElement(raw string) PathElement {
+	if raw == "*" {
+		return PathElement{Wildcard: true}
+	}
+	if index, err := strconv.Atoi(raw); err == nil {
+		return PathElement{Key: raw, Index: index, IsIndex: true}
+	}
+	return PathElement{Key: raw}
+}
+
+func parseBracket(raw string) (PathElement, error) {
+	if raw == "*" {
+		return PathElement{Wildcard: true}, nil
+	}
+
+	index, err := strconv.Atoi(raw)
+	if err != nil {
+		return PathElement{}, fmt.Errorf("[%s] must be an index, * or a quoted key", raw)
+	}
+	return PathElement{Index: index, IsIndex: true}, nil
+}
+
+// parseQuoted returns the key quoted at the start of s, and the length of the quoted key
+func parseQuoted(s string) (string, int, error) {
+	for end := 1; end < len(s); end++ {
+		switch s[end] {
+		case '\\':
+			end++
+		case '"':
+			key, err := strconv.Unquote(s[:end+1])
+			if err != nil {
+				return "", 0, fmt.Errorf("invalid quoted key %s: %w", s[:end+1], err)
+			}
+			return key, end + 1, nil
+		}
+	}
+	return "", 0, fmt.Errorf("missing closing quote of %s", s)
+}
+
+// Lookup returns the value at path in input, and whether it was found. Wildcards return a list of the values found
+// for each element they match
+func Lookup(path string, input interface{}) (interface{}, bool, error) {
+	elements, err := ParsePath(path)
+	if err != nil {
+		return nil, false, err
+	}
+
+	value, found := lookupElements(elements, input)
+	return value, found, nil
+}
+
+func lookupElements(elements []PathElement, input interface{}) (interface{}, bool) {
+	if len(elements) == 0 {
+		return input, true
+	}
+
+	element := elements[0]
+	if element.Wildcard {
+		children, ok := wildcardValues(input)
+		if !ok {
+			return nil, false
+		}
+		values := make([]interface{}, 0, len(children))
+		for _, child := range children {
+			if value, found := lookupElements(elements[1:], child); found {
+				values = append(values, value)
+			}
+		}
+		return values, true
+	}
+
+	next, ok := lookupElement(element, input)
+	if !ok {
+		return nil, false
+	}
+	return lookupElements(elements[1:], next)
+}
+
+func lookupElement(element PathElement, input interface{}) (interface{}, bool) {
+	if list, ok := input.([]interface{}); ok {
+		if !element.IsIndex {
+			return nil, false
+		}
+		index := element.Index
+		if index < 0 {
+			index += len(list)
+		}
+		if index < 0 || index >= len(list) {
+			return nil, false
+		}
+		return list[index], true
+	}
+
+	if element.IsIndex && element.Key == "" {
+		return nil, false
+	}
+	if value, ok := lookup(element.Key, input); ok {
+		return value, true
+	}
+	// yaml decodes numeric keys as ints
+	if m, ok := input.(map[interface{}]interface{}); ok && element.IsIndex {
+		value, ok := m[element.Index]
+		return value, ok
+	}
+	return nil, false
+}
+
+// wildcardValues returns the elements of a list, or the values of a map ordered by key
+func wildcardValues(input interface{}) ([]interface{}, bool) {
+	switch values := input.(type) {
+	case []interface{}:
+		return values, true
+	case map[interface{}]interface{}, map[string]interface{}, map[string]string:
+		pairs := ObjectPairs(values)
+		result := make([]interface{}, 0, len(pairs))
+		for _, pair := range pairs {
+			result = append(result, pair.Value)
+		}
+		return result, true
+	default:
+		return nil, false
+	}
+}
+
+// ObjectPairs returns the key value pairs of a map sorted by key, or none if value isn't a map
+func ObjectPairs(value interface{}) []KeyValuePair {
+	values := []KeyValuePair{}
+	switch typedValue := value.(type) {
+	case map[interface{}]interface{}:
+		for k, v := range typedValue {
+			values = append(values, KeyValuePair{Key: fmt.Sprint(k), Value: v})
+		}
+	case map[string]interface{}:
+		for k, v := range typedValue {
+			values = append(values, KeyValuePair{Key: k, Value: v})
+		}
+	case map[string]string:
+		for k, v := range typedValue {
+			values = append(values, KeyValuePair{Key: k, Value: v})
+		}
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].Key < values[j].Key
+	})
+	return values
+}
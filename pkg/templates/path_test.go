@@ -0,0 +1,100 @@
+package templates
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePath(t *testing.T) {
+	tt := []struct {
+		path     string
+		elements []PathElement
+		err      string
+	}{
+		{
+			path:     "docker.image",
+			elements: []PathElement{{Key: "docker"}, {Key: "image"}},
+		},
+		{
+			path:     "services.0.name",
+			elements: []PathElement{{Key: "services"}, {Key: "0", Index: 0, IsIndex: true}, {Key: "name"}},
+		},
+		{
+			path:     "ports[-1]",
+			elements: []PathElement{{Key: "ports"}, {Index: -1, IsIndex: true}},
+		},
+		{
+			path:     "services[*].ports.*",
+			elements: []PathElement{{Key: "services"}, {Wildcard: true}, {Key: "ports"}, {Wildcard: true}},
+		},
+		{
+			path:     `annotations."lunar.tech/owner".name`,
+			elements: []PathElement{{Key: "annotations"}, {Key: "lunar.tech/owner"}, {Key: "name"}},
+		},
+		{
+			path:     `annotations["a]b"]`,
+			elements: []PathElement{{Key: "annotations"}, {Key: "a]b"}},
+		},
+		{path: "", err: "path is empty"},
+		{path: "a..b", err: "path a..b: empty element at position 2"},
+		{path: "a.", err: "path a.: ends with ."},
+		{path: "a[x]", err: "path a[x]: [x] must be an index, * or a quoted key"},
+		{path: "a[1", err: "path a[1: missing ] after position 1"},
+		{path: `a."b`, err: `path a."b: missing closing quote of "b`},
+		{path: `a."b"c`, err: `path a."b"c: expected . or [ at position 5`},
+	}
+	for _, tc := range tt {
+		t.Run(tc.path, func(t *testing.T) {
+			elements, err := ParsePath(tc.path)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.elements, elements)
+		})
+	}
+}
+
+func TestLookup(t *testing.T) {
+	data := fromYaml(`
+services:
+  - name: api
+    ports: [80, 443]
+  - name: worker
+annotations:
+  lunar.tech/owner: squad
+codes:
+  200: ok
+`)
+	tt := []struct {
+		path  string
+		value interface{}
+		found bool
+	}{
+		{path: "services.0.name", value: "api", found: true},
+		{path: "services[1].name", value: "worker", found: true},
+		{path: "services[-1].name", value: "worker", found: true},
+		{path: "services.0.ports[-2]", value: 80, found: true},
+		{path: "services[2]", found: false},
+		{path: "services[-3]", found: false},
+		{path: "services.name", found: false},
+		{path: "services.*.name", value: []interface{}{"api", "worker"}, found: true},
+		{path: "services.*.ports", value: []interface{}{[]interface{}{80, 443}}, found: true},
+		{path: "annotations.*", value: []interface{}{"squad"}, found: true},
+		{path: "missing.*", found: false},
+		{path: `annotations."lunar.tech/owner"`, value: "squad", found: true},
+		{path: "codes.200", value: "ok", found: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.path, func(t *testing.T) {
+			value, found, err := Lookup(tc.path, data)
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.found, found, "found")
+			assert.Equal(t, tc.value, value)
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package templates
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// Suffix is stripped from the names of rendered files
+const Suffix = ".tmpl"
+
+// PartialPrefix marks template files as partials, i.e. _labels.tmpl is available to templates as
+// {{ include "labels" . }}
+const PartialPrefix = "_"
+
+// IsPartial reports whether the file name is a partial
+func IsPartial(name string) bool {
+	return strings.HasPrefix(name, PartialPrefix) && strings.HasSuffix(name, Suffix)
+}
+
+// PartialName is the name partials are included by, i.e. labels for _labels.tmpl
+func PartialName(file string) string {
+	return strings.TrimSuffix(strings.TrimPrefix(path.Base(file), PartialPrefix), Suffix)
+}
+
+// Renderer renders the file and directory templates of shuttle template. The fields must not be changed once a
+// template is rendered, as the partials are only loaded once
+type Renderer struct {
+	LeftDelim  string
+	RightDelim string
+	// Strict fails rendering on missing vars, see GetStrictFuncMap
+	Strict bool
+	// Partials are the directories with partials available to templates. Earlier directories override partials of the
+	// same name in later ones, i.e. the project overrides the plan
+	Partials []string
+	// Data is passed to the templates as .
+	Data any
+	// Vars are the vars in Data, which strict templates report the full path of missing vars in
+	Vars any
+
+	// partials is the template set of the functions and partials, which templates are cloned from
+	partials *template.Template
+}
+
+// newTemplate returns a template with the functions and partials available to templates
+func (r *Renderer) newTemplate(name string) (*template.Template, error) {
+	if r.partials == nil {
+		partials, err := r.loadPartials()
+		if err != nil {
+			return nil, err
+		}
+		r.partials = partials
+	}
+
+	tmpl, err := r.partials.Clone()
+	if err != nil {
+		return nil, err
+	}
+	// include executes the partial in the template set of the clone, which includes the templates it defines
+	tmpl.Funcs(template.FuncMap{
+		"include": func(name string, data any) (string, error) {
+			var output strings.Builder
+			if err := tmpl.ExecuteTemplate(&output, name, data); err != nil {
+				return "", err
+			}
+			return output.String(), nil
+		},
+	})
+
+	return tmpl.New(name), nil
+}
+
+// loadPartials returns a template set with the functions and partials available to templates. It is never executed,
+// so it can be cloned for every template
+func (r *Renderer) loadPartials() (*template.Template, error) {
+	tmpl := template.New("partials").Delims(r.LeftDelim, r.RightDelim)
+	if r.Strict {
+		tmpl = tmpl.Option("missingkey=error").Funcs(GetStrictFuncMapOf(r.Vars))
+	} else {
+		tmpl = tmpl.Funcs(GetFuncMap())
+	}
+	// include renders a partial to a string, so it can be piped to i.e. nindent. It is bound to the set of each
+	// template in newTemplate
+	tmpl = tmpl.Funcs(template.FuncMap{
+		"include": func(name string, data any) (string, error) {
+			return "", errors.New("include is not bound to a template")
+		},
+	})
+
+	// later partials are parsed first, so earlier ones replace them
+	for i := len(r.Partials) - 1; i >= 0; i-- {
+		entries, err := os.ReadDir(r.Partials[i])
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !IsPartial(entry.Name()) {
+				continue
+			}
+
+			file := filepath.Join(r.Partials[i], entry.Name())
+			content, err := os.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			if _, err := tmpl.New(PartialName(file)).Parse(string(content)); err != nil {
+				return nil, fmt.Errorf("parse partial %s: %w", file, err)
+			}
+		}
+	}
+
+	return tmpl, nil
+}
+
+// ParseFile parses the template in templatePath, which is named by its base name
+func (r *Renderer) ParseFile(templatePath string) (*template.Template, error) {
+	tmpl, err := r.newTemplate(path.Base(templatePath))
+	if err != nil {
+		return nil, err
+	}
+	return tmpl.ParseFiles(templatePath)
+}
+
+// RenderFile renders the template in templatePath to w
+func (r *Renderer) RenderFile(w io.Writer, templatePath string) error {
+	tmpl, err := r.ParseFile(templatePath)
+	if err != nil {
+		return err
+	}
+
+	return tmpl.Execute(w, r.Data)
+}
+
+// File is a file rendered from a directory template
+type File struct {
+	// Path is relative to the output directory, with the names rendered and the Suffix stripped
+	Path string
+	// Source is the template the file is rendered from
+	Source  string
+	Content []byte
+	Mode    fs.FileMode
+}
+
+// RenderDir renders every file in the template directories sources. Files are identified by their path relative to
+// the source, and earlier sources override later ones, i.e. the project overrides the plan.
+//
+// The names of files and directories are templates as well. Files rendering to only whitespace are skipped, as are
+// files and directories whose name renders empty, and partials
+func (r *Renderer) RenderDir(sources ...string) ([]File, error) {
+	// maps the path relative to the sources to the template used for it
+	templates := make(map[string]string)
+	for i := len(sources) - 1; i >= 0; i-- {
+		err := filepath.WalkDir(sources[i], func(file string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if entry.IsDir() || IsPartial(entry.Name()) {
+				return nil
+			}
+
+			relative, err := filepath.Rel(sources[i], file)
+			if err != nil {
+				return err
+			}
+			templates[filepath.ToSlash(relative)] = file
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	relatives := make([]string, 0, len(templates))
+	for relative := range templates {
+		relatives = append(relatives, relative)
+	}
+	sort.Strings(relatives)
+
+	files := make([]File, 0, len(relatives))
+	rendered := make(map[string]string)
+	for _, relative := range relatives {
+		source := templates[relative]
+
+		name, err := r.renderPath(relative)
+		if err != nil {
+			return nil, fmt.Errorf("render name of %s: %w", source, err)
+		}
+		if name == "" {
+			continue
+		}
+		if other, ok := rendered[name]; ok {
+			return nil, fmt.Errorf("both %s and %s render to %s", other, source, name)
+		}
+		rendered[name] = source
+
+		info, err := os.Stat(source)
+		if err != nil {
+			return nil, err
+		}
+
+		var content bytes.Buffer
+		if err := r.RenderFile(&content, source); err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(content.String()) == "" {
+			continue
+		}
+
+		files = append(files, File{
+			Path:    name,
+			Source:  source,
+			Content: content.Bytes(),
+			Mode:    info.Mode().Perm(),
+		})
+	}
+
+	return files, nil
+}
+
+// renderPath renders each element of the slash separated relative path, and strips the Suffix. It returns an empty
+// path if any element renders empty
+func (r *Renderer) renderPath(relative string) (string, error) {
+	elements := strings.Split(relative, "/")
+	for i, element := range elements {
+		tmpl, err := r.newTemplate(relative)
+		if err != nil {
+			return "", err
+		}
+		tmpl, err = tmpl.Parse(element)
+		if err != nil {
+			return "", err
+		}
+
+		var name strings.Builder
+		if err := tmpl.Execute(&name, r.Data); err != nil {
+			return "", err
+		}
+		elements[i] = strings.TrimSpace(name.String())
+		if elements[i] == "" {
+			return "", nil
+		}
+		if strings.Contains(elements[i], "/") || elements[i] == "." || elements[i] == ".." {
+			return "", fmt.Errorf("name %q rendered from %q must be a single path element", elements[i], element)
+		}
+	}
+
+	last := len(elements) - 1
+	elements[last] = strings.TrimSuffix(elements[last], Suffix)
+	if elements[last] == "" {
+		return "", errors.New("file name is empty without the " + Suffix + " suffix")
+	}
+
+	return path.Join(elements...), nil
+}
+
+// WriteFiles writes files into outputDir, creating directories as needed
+func WriteFiles(outputDir string, files []File) error {
+	for _, file := range files {
+		filePath := filepath.Join(outputDir, filepath.FromSlash(file.Path))
+		if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filePath, file.Content, file.Mode); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,257 @@
+package templates
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTemplate(t *testing.T, file, content string) {
+	t.Helper()
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(file), 0o755))
+	require.NoError(t, os.WriteFile(file, []byte(content), 0o644))
+}
+
+func TestRenderDir(t *testing.T) {
+	root := t.TempDir()
+	plan := filepath.Join(root, "plan")
+	project := filepath.Join(root, "project")
+
+	writeTemplate(t, filepath.Join(plan, "deployment.yaml.tmpl"), "name: {{ .service }}\n")
+	writeTemplate(t, filepath.Join(plan, "{{ .service }}/config.yaml"), "replicas: {{ .replicas }}\n")
+	writeTemplate(t, filepath.Join(plan, "ingress.yaml.tmpl"), "{{ if .public }}host: example.com{{ end }}\n")
+	writeTemplate(t, filepath.Join(plan, "{{ if .public }}public{{ end }}/cert.yaml"), "cert: true\n")
+	writeTemplate(t, filepath.Join(plan, "service.yaml"), "from: plan\n")
+	writeTemplate(t, filepath.Join(project, "service.yaml"), "from: project\n")
+
+	renderer := &Renderer{
+		LeftDelim:  "{{",
+		RightDelim: "}}",
+		Data: map[string]any{
+			"service":  "shuttle",
+			"replicas": 2,
+			"public":   false,
+		},
+	}
+
+	files, err := renderer.RenderDir(project, plan)
+	require.NoError(t, err)
+
+	rendered := make(map[string]string)
+	for _, file := range files {
+		rendered[file.Path] = string(file.Content)
+	}
+	assert.Equal(t, map[string]string{
+		"deployment.yaml":     "name: shuttle\n",
+		"shuttle/config.yaml": "replicas: 2\n",
+		"service.yaml":        "from: project\n",
+	}, rendered)
+
+	outputDir := filepath.Join(root, "output")
+	require.NoError(t, WriteFiles(outputDir, files))
+	content, err := os.ReadFile(filepath.Join(outputDir, "shuttle/config.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, "replicas: 2\n", string(content))
+}
+
+func TestRenderDirInvalidName(t *testing.T) {
+	plan := t.TempDir()
+	writeTemplate(t, filepath.Join(plan, "{{ .name }}.yaml"), "a: b\n")
+
+	renderer := &Renderer{
+		LeftDelim:  "{{",
+		RightDelim: "}}",
+		Data:       map[string]any{"name": "../escape"},
+	}
+
+	_, err := renderer.RenderDir(plan)
+
+	assert.ErrorContains(t, err, "must be a single path element")
+}
+
+func TestRenderFileStrict(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "deployment.yaml.tmpl")
+	writeTemplate(t, file, "name: {{ .Vars.service }}\nimage: {{ string \"image.name\" .Vars }}\n")
+
+	tt := []struct {
+		name   string
+		strict bool
+		output string
+		err    string
+	}{
+		{
+			name:   "lenient",
+			output: "name: shuttle\nimage: \n",
+		},
+		{
+			name:   "strict",
+			strict: true,
+			err:    `template: deployment.yaml.tmpl:2:10: executing "deployment.yaml.tmpl" at <string "image.name" .Vars>: error calling string: var image.name is missing`,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			renderer := &Renderer{
+				LeftDelim:  "{{",
+				RightDelim: "}}",
+				Strict:     tc.strict,
+				Data:       map[string]any{"Vars": map[string]any{"service": "shuttle"}},
+			}
+
+			var output strings.Builder
+			err := renderer.RenderFile(&output, file)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.output, output.String())
+		})
+	}
+}
+
+func TestRenderFileStrictMissingKey(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "deployment.yaml.tmpl")
+	writeTemplate(t, file, "name: {{ .Vars.sevrice }}\n")
+
+	renderer := &Renderer{
+		LeftDelim:  "{{",
+		RightDelim: "}}",
+		Strict:     true,
+		Data:       map[string]any{"Vars": map[string]any{"service": "shuttle"}},
+	}
+
+	err := renderer.RenderFile(io.Discard, file)
+
+	assert.EqualError(t, err, `template: deployment.yaml.tmpl:1:14: executing "deployment.yaml.tmpl" at <.Vars.sevrice>: map has no entry for key "sevrice"`)
+}
+
+func TestRenderFileStrictFullPath(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "deployment.yaml.tmpl")
+	writeTemplate(t, file, "image: {{ string \"image\" .Vars.docker }}\n")
+
+	vars := map[string]any{"docker": map[string]any{"registry": "quay.io"}}
+	renderer := &Renderer{
+		LeftDelim:  "{{",
+		RightDelim: "}}",
+		Strict:     true,
+		Data:       map[string]any{"Vars": vars},
+		Vars:       vars,
+	}
+
+	err := renderer.RenderFile(io.Discard, file)
+
+	assert.EqualError(t, err, `template: deployment.yaml.tmpl:1:10: executing "deployment.yaml.tmpl" at <string "image" .Vars.docker>: error calling string: var docker.image is missing`)
+}
+
+func TestRenderFilePartialsReused(t *testing.T) {
+	root := t.TempDir()
+	partials := filepath.Join(root, "partials")
+	writeTemplate(t, filepath.Join(partials, "_name.tmpl"), "{{ .service }}")
+	writeTemplate(t, filepath.Join(root, "a.tmpl"), `{{ define "greeting" }}hello {{ include "name" . }}{{ end }}{{ include "greeting" . }}`)
+	writeTemplate(t, filepath.Join(root, "b.tmpl"), `bye {{ include "name" . }}`)
+
+	renderer := &Renderer{
+		LeftDelim:  "{{",
+		RightDelim: "}}",
+		Partials:   []string{partials},
+		Data:       map[string]any{"service": "shuttle"},
+	}
+
+	var a strings.Builder
+	require.NoError(t, renderer.RenderFile(&a, filepath.Join(root, "a.tmpl")))
+	assert.Equal(t, "hello shuttle", a.String())
+
+	// partials are loaded once, so later changes aren't picked up
+	writeTemplate(t, filepath.Join(partials, "_name.tmpl"), "changed")
+	var b strings.Builder
+	require.NoError(t, renderer.RenderFile(&b, filepath.Join(root, "b.tmpl")))
+	assert.Equal(t, "bye shuttle", b.String())
+}
+
+func TestRenderFilePartials(t *testing.T) {
+	root := t.TempDir()
+	plan := filepath.Join(root, "plan")
+	project := filepath.Join(root, "project")
+
+	writeTemplate(t, filepath.Join(plan, "_labels.tmpl"), "service: {{ .service }}\nowner: plan")
+	writeTemplate(t, filepath.Join(plan, "_owner.tmpl"), "owner: plan")
+	writeTemplate(t, filepath.Join(project, "_owner.tmpl"), "owner: project")
+	writeTemplate(t, filepath.Join(plan, "deployment.yaml.tmpl"), `metadata:
+  labels:
+    {{- include "labels" . | nindent 4 }}
+  annotations:
+    {{- include "owner" . | nindent 4 }}
+`)
+
+	tt := []struct {
+		name     string
+		partials []string
+		output   string
+	}{
+		{
+			name:     "project overrides plan",
+			partials: []string{project, plan},
+			output: `metadata:
+  labels:
+    service: shuttle
+    owner: plan
+  annotations:
+    owner: project
+`,
+		},
+		{
+			name:     "plan only",
+			partials: []string{plan},
+			output: `metadata:
+  labels:
+    service: shuttle
+    owner: plan
+  annotations:
+    owner: plan
+`,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			renderer := &Renderer{
+				LeftDelim:  "{{",
+				RightDelim: "}}",
+				Partials:   tc.partials,
+				Data:       map[string]any{"service": "shuttle"},
+			}
+
+			var output strings.Builder
+			err := renderer.RenderFile(&output, filepath.Join(plan, "deployment.yaml.tmpl"))
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.output, output.String())
+		})
+	}
+}
+
+func TestRenderDirSkipsPartials(t *testing.T) {
+	plan := t.TempDir()
+	writeTemplate(t, filepath.Join(plan, "_name.tmpl"), "{{ .service }}")
+	writeTemplate(t, filepath.Join(plan, "service.yaml.tmpl"), "name: {{ include \"name\" . }}\n")
+
+	renderer := &Renderer{
+		LeftDelim:  "{{",
+		RightDelim: "}}",
+		Partials:   []string{plan},
+		Data:       map[string]any{"service": "shuttle"},
+	}
+
+	files, err := renderer.RenderDir(plan)
+
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, "service.yaml", files[0].Path)
+	assert.Equal(t, "name: shuttle\n", string(files[0].Content))
+}
@@ -0,0 +1,135 @@
+package templates
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// varPaths maps the maps and lists of the vars to their path, so the strict functions can report the full path of
+// missing vars. Template functions are only passed the value of i.e. .Vars.docker, so it is looked up by identity
+type varPaths map[varRef]string
+
+type varRef struct {
+	kind    reflect.Kind
+	pointer uintptr
+	len     int
+}
+
+// indexVarPaths returns the paths of the maps and lists in vars
+func indexVarPaths(vars interface{}) varPaths {
+	paths := varPaths{}
+	paths.add(nil, reflect.ValueOf(vars))
+	return paths
+}
+
+func (p varPaths) add(path []PathElement, value reflect.Value) {
+	for value.Kind() == reflect.Interface || value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return
+		}
+		value = value.Elem()
+	}
+
+	ref, ok := refOf(value)
+	if !ok {
+		return
+	}
+	// values referenced more than once keep their first path, which also stops cycles
+	if _, ok := p[ref]; ok {
+		return
+	}
+	p[ref] = formatPath(path)
+
+	switch value.Kind() {
+	case reflect.Map:
+		iter := value.MapRange()
+		for iter.Next() {
+			p.add(appendElement(path, PathElement{Key: fmt.Sprint(iter.Key().Interface())}), iter.Value())
+		}
+	case reflect.Slice:
+		for i := 0; i < value.Len(); i++ {
+			p.add(appendElement(path, PathElement{Index: i, IsIndex: true}), value.Index(i))
+		}
+	}
+}
+
+// fullPath returns path prefixed with the path of input in the vars, or path if input isn't part of them
+func (p varPaths) fullPath(path string, input interface{}) string {
+	ref, ok := refOf(reflect.ValueOf(input))
+	if !ok {
+		return path
+	}
+	prefix, ok := p[ref]
+	if !ok || prefix == "" {
+		return path
+	}
+	if strings.HasPrefix(path, "[") {
+		return prefix + path
+	}
+	return prefix + "." + path
+}
+
+func (p varPaths) get(path string, input interface{}) (interface{}, error) {
+	value, found, err := Lookup(path, input)
+	if err != nil {
+		return nil, err
+	}
+	if !found || value == nil {
+		return nil, fmt.Errorf("var %s is missing", p.fullPath(path, input))
+	}
+	return value, nil
+}
+
+func (p varPaths) string(path string, input interface{}) (string, error) {
+	value, err := p.get(path, input)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%v", value), nil
+}
+
+func (p varPaths) int(path string, input interface{}) (int, error) {
+	value, err := p.get(path, input)
+	if err != nil {
+		return 0, err
+	}
+	i, ok := value.(int)
+	if !ok {
+		return 0, fmt.Errorf("var %s is %T, not an int", p.fullPath(path, input), value)
+	}
+	return i, nil
+}
+
+// refOf returns the identity of a non-empty map or list. Empty lists are left out, as they may share their pointer
+func refOf(value reflect.Value) (varRef, bool) {
+	switch value.Kind() {
+	case reflect.Map:
+		if value.IsNil() {
+			return varRef{}, false
+		}
+		return varRef{kind: reflect.Map, pointer: value.Pointer()}, true
+	case reflect.Slice:
+		if value.Len() == 0 {
+			return varRef{}, false
+		}
+		return varRef{kind: reflect.Slice, pointer: value.Pointer(), len: value.Len()}, true
+	default:
+		return varRef{}, false
+	}
+}
+
+func appendElement(path []PathElement, element PathElement) []PathElement {
+	return append(path[:len(path):len(path)], element)
+}
+
+func formatPath(path []PathElement) string {
+	var s strings.Builder
+	for i, element := range path {
+		if i > 0 && !(element.IsIndex && element.Key == "") {
+			s.WriteByte('.')
+		}
+		s.WriteString(element.String())
+	}
+	return s.String()
+}